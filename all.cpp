@@ -2,6 +2,8 @@
 #include "cpp/capi.cpp"
 #include "cpp/govalue.cpp"
 #include "cpp/govaluetype.cpp"
+#include "cpp/scenegraph.cpp"
+#include "cpp/painter.cpp"
 #include "cpp/idletimer.cpp"
 #include "cpp/connector.cpp"
 
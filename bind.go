@@ -0,0 +1,138 @@
+package qml
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// bindings holds the bindings established for each field address.
+// It must only be accessed from the main GUI thread.
+var bindings = make(map[uintptr][]*Binding)
+
+// Binding keeps a Go field and a QML property in sync. See Bind.
+type Binding struct {
+	obj      Object
+	property string
+	field    reflect.Value
+	addr     uintptr
+	toQML    func(interface{}) interface{}
+	toGo     func(interface{}) interface{}
+	conn     *Connection
+
+	// updating prevents changes from bouncing back and forth.
+	updating bool
+}
+
+// Bind keeps the Go field at fieldAddr and the named property of obj
+// in sync in both directions. The property is first set to the current
+// field value, and from then on changes to the property are assigned to
+// the field, and changes to the field reported via Changed are assigned
+// to the property.
+//
+// The field is assigned from the main GUI thread, so reading it from other
+// goroutines must be synchronized, for example by running the read via
+// RunMain or while holding Lock.
+//
+// For example:
+//
+//     binding := qml.Bind(obj, "text", &model.Name)
+//     ...
+//     model.Name = "New name"
+//     qml.Changed(model, &model.Name)
+//     ...
+//     binding.Unbind()
+//
+// Bind panics if the property does not exist or has no notify signal.
+func Bind(obj Object, property string, fieldAddr interface{}) *Binding {
+	return BindWith(obj, property, fieldAddr, nil, nil)
+}
+
+// BindWith works like Bind, but converts the field value with toQML before
+// assigning it to the property, and the property value with toGo before
+// assigning it to the field. Either converter may be nil.
+func BindWith(obj Object, property string, fieldAddr interface{}, toQML, toGo func(interface{}) interface{}) *Binding {
+	fieldv := reflect.ValueOf(fieldAddr)
+	if fieldv.Kind() != reflect.Ptr || fieldv.IsNil() {
+		panic("qml.Bind received non-address value as fieldAddr")
+	}
+	b := &Binding{
+		obj:      obj,
+		property: property,
+		field:    fieldv.Elem(),
+		addr:     fieldv.Pointer(),
+		toQML:    toQML,
+		toGo:     toGo,
+	}
+	RunMain(func() {
+		b.pushToQML()
+		b.conn = obj.Watch(property, func(old, new interface{}) { b.pullFromQML(new) })
+		// Forget the binding once the property can't be watched anymore,
+		// whether due to Unbind, to Off, or to obj being destroyed.
+		b.conn.broken = b.remove
+		bindings[b.addr] = append(bindings[b.addr], b)
+	})
+	return b
+}
+
+// Unbind stops keeping the field and the property in sync.
+// It is safe to call Unbind more than once.
+func (b *Binding) Unbind() {
+	b.conn.Disconnect()
+}
+
+// remove drops b from the bindings of its field.
+func (b *Binding) remove() {
+	var list []*Binding
+	for _, other := range bindings[b.addr] {
+		if other != b {
+			list = append(list, other)
+		}
+	}
+	if len(list) == 0 {
+		delete(bindings, b.addr)
+	} else {
+		bindings[b.addr] = list
+	}
+}
+
+// pushToQML assigns the field value to the property, unless the binding
+// was broken meanwhile, in which case obj may not even exist anymore.
+func (b *Binding) pushToQML() {
+	if b.updating || b.conn != nil && b.conn.disconnected {
+		return
+	}
+	b.updating = true
+	defer func() { b.updating = false }()
+
+	value := b.field.Interface()
+	if b.toQML != nil {
+		value = b.toQML(value)
+	}
+	b.obj.Set(b.property, value)
+}
+
+// pullFromQML assigns the provided property value to the field, and
+// propagates it to other properties bound to the same field.
+func (b *Binding) pullFromQML(value interface{}) {
+	if b.updating {
+		return
+	}
+	b.updating = true
+	defer func() { b.updating = false }()
+
+	if b.toGo != nil {
+		value = b.toGo(value)
+	}
+	v, ok := convertValue(value, b.field.Type())
+	if !ok {
+		panic(fmt.Sprintf("cannot convert value of property %q from %T to %s; provided value: %#v",
+			b.property, value, b.field.Type(), value))
+	}
+	b.field.Set(v)
+
+	for _, other := range bindings[b.addr] {
+		if other != b {
+			other.pushToQML()
+		}
+	}
+}
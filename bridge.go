@@ -12,25 +12,37 @@ package qml
 import "C"
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"reflect"
 	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
 	"sync/atomic"
+	"time"
 	"unsafe"
 
 	"gopkg.in/qml.v1/cdata"
 )
 
 var (
-	guiFunc      = make(chan func())
-	guiDone      = make(chan struct{})
-	guiLock      = 0
-	guiMainRef   uintptr
-	guiPaintRef  uintptr
-	guiIdleRun   int32
-
-	initialized int32
+	guiFunc     = make(chan func())
+	guiDone     = make(chan struct{})
+	guiLock     = 0
+	guiMainRef  uintptr
+	guiPaintRef uintptr
+	guiIdleRun  int32
+
+	guiAsync      []func()
+	guiAsyncMutex sync.Mutex
+
+	appRunning    int32
+	appGeneration int
+	appQuitOnLast bool
+	appMutex      sync.Mutex
+	aboutToQuit   []func()
 )
 
 func init() {
@@ -43,25 +55,215 @@ func init() {
 //
 // Most functions from the qml package block until Run is called.
 //
+// The event loop may also be terminated earlier via Quit, or when the
+// last window is closed if SetQuitOnLastWindowClosed(true) was called.
+// In those cases Run returns without waiting for f to return, and the
+// result is nil, or an *ExitError if the exit code is not zero.
+//
+// Once the event loop terminates the underlying application is torn down,
+// and Run may be called again to create a new one. Engines and objects
+// obtained while the previous application was running must not be used
+// after that.
+//
 // The Run function must necessarily be called from the same goroutine as
 // the main function or the application may fail when running on Mac OS.
 func Run(f func() error) error {
+	return RunContext(context.Background(), f)
+}
+
+// RunContext works like Run, but also terminates the event loop once
+// ctx is done, in which case ctx.Err() is returned without waiting for
+// f to return.
+func RunContext(ctx context.Context, f func() error) error {
+	return run(ctx, nil, f)
+}
+
+// Options holds settings for the application created by RunWithOptions.
+// Several of these must necessarily be defined before the application
+// is created.
+type Options struct {
+	// GuiApplication defines whether a lighter QGuiApplication is
+	// created instead of the default QApplication, which also supports
+	// QtWidgets-based functionality such as native dialogs in some platforms.
+	GuiApplication bool
+
+	// Args holds the command line arguments handed to the application,
+	// starting with the program name. Qt takes into account arguments it
+	// knows about, such as -platform and -style.
+	Args []string
+
+	// OrganizationName, OrganizationDomain, ApplicationName, and
+	// ApplicationVersion identify the application, and are used by Qt
+	// when locating settings and other per-application data.
+	OrganizationName   string
+	OrganizationDomain string
+	ApplicationName    string
+	ApplicationVersion string
+
+	// HighDPIScaling enables the automatic scaling of the user interface
+	// according to the pixel density of screens. It requires Qt 5.6.
+	HighDPIScaling bool
+
+	// OpenGL selects the OpenGL implementation used for rendering.
+	OpenGL OpenGLImplementation
+
+	// ShareOpenGLContexts enables the sharing of resources between the
+	// OpenGL contexts of different windows. It requires Qt 5.4.
+	ShareOpenGLContexts bool
+}
+
+// OpenGLImplementation selects the OpenGL implementation used for
+// rendering, for platforms where more than one option is available.
+type OpenGLImplementation int
+
+const (
+	OpenGLDefault  OpenGLImplementation = iota
+	OpenGLDesktop                       // Desktop OpenGL; requires Qt 5.3
+	OpenGLES                            // OpenGL ES, via ANGLE on Windows; requires Qt 5.3
+	OpenGLSoftware                      // Software rasterizer; requires Qt 5.4
+)
+
+// RunWithOptions works like Run, but creates the application according
+// to the provided options. An error is returned without running f if the
+// options are not supported by the Qt version in use.
+func RunWithOptions(opts *Options, f func() error) error {
+	return run(context.Background(), opts, f)
+}
+
+func run(ctx context.Context, opts *Options, f func() error) error {
 	if cdata.Ref() != guiMainRef {
 		panic("Run must be called on the initial goroutine so apps are portable to Mac OS")
 	}
-	if !atomic.CompareAndSwapInt32(&initialized, 0, 1) {
-		panic("qml.Run called more than once")
+	if !atomic.CompareAndSwapInt32(&appRunning, 0, 1) {
+		panic("qml.Run called while the event loop is already running")
+	}
+	defer atomic.StoreInt32(&appRunning, 0)
+
+	appGeneration++
+	generation := appGeneration
+	exit := func() {
+		if appGeneration == generation {
+			C.applicationExit(0)
+		}
+	}
+
+	if opts == nil {
+		opts = &Options{}
+	}
+	args := opts.Args
+	if len(args) == 0 {
+		args = []string{""}
+	}
+	var copts C.ApplicationOptions
+	copts.guiApplication = cbool(opts.GuiApplication)
+	copts.argc = C.int(len(args))
+	copts.args, copts.argsLen = unsafeStringData(strings.Join(args, "\x00"))
+	copts.organizationName, copts.organizationNameLen = unsafeStringData(opts.OrganizationName)
+	copts.organizationDomain, copts.organizationDomainLen = unsafeStringData(opts.OrganizationDomain)
+	copts.applicationName, copts.applicationNameLen = unsafeStringData(opts.ApplicationName)
+	copts.applicationVersion, copts.applicationVersionLen = unsafeStringData(opts.ApplicationVersion)
+	copts.highDPIScaling = cbool(opts.HighDPIScaling)
+	copts.openGL = C.OpenGLImpl(opts.OpenGL)
+	copts.shareOpenGLContexts = cbool(opts.ShareOpenGLContexts)
+
+	appMutex.Lock()
+	copts.quitOnLastWindowClosed = cbool(appQuitOnLast)
+	appMutex.Unlock()
+	if cerr := C.newGuiApplication(&copts); cerr != nil {
+		return cerror(cerr)
 	}
-	C.newGuiApplication()
 	C.idleTimerInit((*C.int32_t)(&guiIdleRun))
-	done := make(chan error, 1)
+	if atomic.LoadInt32(&guiIdleRun) > 0 {
+		// Functions were handed over while no application was running.
+		C.idleTimerStart()
+	}
+
+	done := make(chan error, 2)
 	go func() {
 		RunMain(func() {}) // Block until the event loop is running.
 		done <- f()
-		C.applicationExit()
+		RunMainAsync(exit)
 	}()
-	C.applicationExec()
-	return <-done
+	exited := make(chan struct{})
+	if ctx.Done() != nil {
+		go func() {
+			select {
+			case <-ctx.Done():
+				done <- ctx.Err()
+				// Exit from within the loop, as exiting before it runs has no effect.
+				RunMainAsync(exit)
+			case <-exited:
+			}
+		}()
+	}
+	code := int(C.applicationExec())
+	close(exited)
+	C.applicationDestroy()
+
+	select {
+	case err := <-done:
+		return err
+	default:
+	}
+	if code != 0 {
+		return &ExitError{code}
+	}
+	return nil
+}
+
+// ExitError is returned by Run when the event loop is terminated
+// via Quit with a non-zero exit code.
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("application exited with code %d", e.Code)
+}
+
+// Quit terminates the main event loop started by Run, making Run return
+// with the provided exit code. Quit does nothing if Run is not running.
+func Quit(code int) {
+	if atomic.LoadInt32(&appRunning) == 0 {
+		return
+	}
+	RunMain(func() {
+		C.applicationExit(C.int(code))
+	})
+}
+
+// OnAboutToQuit registers f to be called in the main QML thread when
+// the event loop started by Run is about to terminate, whatever the
+// reason. The registration is kept across runs.
+func OnAboutToQuit(f func()) {
+	appMutex.Lock()
+	aboutToQuit = append(aboutToQuit, f)
+	appMutex.Unlock()
+}
+
+//export hookApplicationAboutToQuit
+func hookApplicationAboutToQuit() {
+	appMutex.Lock()
+	funcs := aboutToQuit
+	appMutex.Unlock()
+	for _, f := range funcs {
+		f()
+	}
+}
+
+// SetQuitOnLastWindowClosed defines whether the event loop started by Run
+// terminates once the last visible window is closed. It's disabled by default.
+// The setting is kept across runs.
+func SetQuitOnLastWindowClosed(quit bool) {
+	appMutex.Lock()
+	appQuitOnLast = quit
+	appMutex.Unlock()
+	if atomic.LoadInt32(&appRunning) == 0 {
+		return
+	}
+	RunMain(func() {
+		C.applicationSetQuitOnLastWindowClosed(cbool(quit))
+	})
 }
 
 // RunMain runs f in the main QML thread and waits for f to return.
@@ -69,23 +271,136 @@ func Run(f func() error) error {
 // This is meant to be used by extensions that integrate directly with the
 // underlying QML logic.
 func RunMain(f func()) {
+	runMain(context.Background(), f, nil)
+}
+
+// RunMainContext runs f in the main QML thread and waits for f to return,
+// or for ctx to be done. In the latter case ctx.Err() is returned, and
+// f may still run later or be running already, since it cannot be
+// interrupted. If ctx is done before RunMainContext is called, f is
+// not run at all.
+//
+// This is meant to be used by extensions that integrate directly with the
+// underlying QML logic.
+func RunMainContext(ctx context.Context, f func()) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return runMain(ctx, f, nil)
+}
+
+// runMain hands f over to the main GUI thread and waits for it to run,
+// or for ctx to be done. If ctx is done after f was handed over,
+// abandon is called once f is done executing, if it's not nil.
+//
+// runMain must be called directly by the exported function handing f
+// over, so that the proper caller is recorded by the enabled monitoring.
+func runMain(ctx context.Context, f func(), abandon func()) error {
 	ref := cdata.Ref()
 	if ref == guiMainRef || ref == atomic.LoadUintptr(&guiPaintRef) {
 		// Already within the GUI or render threads. Attempting to wait would deadlock.
 		f()
-		return
+		return nil
 	}
 
+	f = wrapMain(2, f)
+
 	// Tell Qt we're waiting for the idle hook to be called.
 	if atomic.AddInt32(&guiIdleRun, 1) == 1 {
 		C.idleTimerStart()
 	}
 
 	// Send f to be executed by the idle hook in the main GUI thread.
-	guiFunc <- f
+	select {
+	case guiFunc <- f:
+	case <-ctx.Done():
+		atomic.AddInt32(&guiIdleRun, -1)
+		return ctx.Err()
+	}
 
 	// Wait until f is done executing.
-	<-guiDone
+	select {
+	case <-guiDone:
+		return nil
+	case <-ctx.Done():
+		// The idle hook blocks until someone receives from guiDone.
+		// No one else may be waiting on it, since the next function
+		// is only taken once this one is done.
+		go func() {
+			<-guiDone
+			if abandon != nil {
+				abandon()
+			}
+		}()
+		return ctx.Err()
+	}
+}
+
+// RunMainAsync schedules f to run in the main QML thread and returns
+// immediately, without waiting for f to run. The returned channel is
+// closed once f returns, and may be ignored if completion is irrelevant.
+//
+// Functions scheduled this way run in the order they were scheduled, and
+// all the functions pending when the main QML thread becomes available
+// run in one batch. That makes RunMainAsync much cheaper than RunMain for
+// high-frequency updates coming from other goroutines.
+//
+// Since nothing waits for f to run, a panic in f is logged (see SetLogger)
+// instead of crashing the application, and the returned channel is still
+// closed.
+//
+// This is meant to be used by extensions that integrate directly with the
+// underlying QML logic.
+func RunMainAsync(f func()) <-chan struct{} {
+	done := make(chan struct{})
+	_, file, line, _ := runtime.Caller(1)
+	run := f
+	f = wrapMain(1, func() {
+		defer recoverAsync(file, line)
+		run()
+	})
+	guiAsyncMutex.Lock()
+	guiAsync = append(guiAsync, func() {
+		defer close(done)
+		f()
+	})
+	if len(guiAsync) == 1 {
+		// Tell Qt we're waiting for the idle hook to be called.
+		// The whole batch is accounted for as a single call.
+		if atomic.AddInt32(&guiIdleRun, 1) == 1 {
+			C.idleTimerStart()
+		}
+	}
+	guiAsyncMutex.Unlock()
+	return done
+}
+
+// wrapMain returns f wrapped with the monitoring enabled for functions
+// run in the main QML thread. The recorded caller stack skips the provided
+// number of frames, with zero identifying the caller of wrapMain.
+func wrapMain(skip int, f func()) func() {
+	f = watchdogWrap(skip+1, f)
+
+	if stats != nil {
+		queued := time.Now()
+		run := f
+		f = func() {
+			stats.runMainCall(time.Since(queued))
+			run()
+		}
+	}
+
+	if stack := captureStack(skip + 1); stack != nil {
+		run := f
+		f = func() {
+			prev := guiStack
+			guiStack = stack
+			run()
+			guiStack = prev
+		}
+	}
+
+	return f
 }
 
 // Lock freezes all QML activity by blocking the main event loop.
@@ -126,6 +441,7 @@ func Flush() {
 }
 
 // Changed notifies all QML bindings that the given field value has changed.
+// Properties bound to the field via Bind are updated as well.
 //
 // For example:
 //
@@ -150,6 +466,9 @@ func Changed(value, fieldAddr interface{}) {
 	}
 
 	RunMain(func() {
+		for _, b := range bindings[fieldv.UnsafeAddr()] {
+			b.pushToQML()
+		}
 		tinfo := typeInfo(value)
 		for _, engine := range engines {
 			fold := engine.values[value]
@@ -176,6 +495,16 @@ func Changed(value, fieldAddr interface{}) {
 //
 //export hookIdleTimer
 func hookIdleTimer() {
+	stats.idleIteration()
+
+	guiAsyncMutex.Lock()
+	batch := guiAsync
+	guiAsync = nil
+	guiAsyncMutex.Unlock()
+	if batch != nil {
+		runAsyncBatch(batch)
+	}
+
 	var f func()
 	for {
 		select {
@@ -193,6 +522,30 @@ func hookIdleTimer() {
 	}
 }
 
+// runAsyncBatch runs the functions scheduled by RunMainAsync, which are
+// accounted for in guiIdleRun as a single pending call.
+func runAsyncBatch(batch []func()) {
+	for _, f := range batch {
+		f()
+	}
+	atomic.AddInt32(&guiIdleRun, -1)
+}
+
+// recoverAsync logs the panic of a function scheduled by RunMainAsync
+// at file and line. Such functions run straight from the Qt event loop,
+// where a panic would take the whole process down, and with nothing
+// waiting on them that could report the problem instead.
+func recoverAsync(file string, line int) {
+	if r := recover(); r != nil {
+		logOutput(&goLogMessage{
+			severity: LogCritical,
+			text:     fmt.Sprintf("panic in function run by RunMainAsync: %v\n%s", r, debug.Stack()),
+			file:     file,
+			line:     line,
+		})
+	}
+}
+
 type valueFold struct {
 	engine *Engine
 	gvalue interface{}
@@ -201,6 +554,7 @@ type valueFold struct {
 	prev   *valueFold
 	next   *valueFold
 	owner  valueOwner
+	stack  []uintptr
 }
 
 type valueOwner uint8
@@ -208,8 +562,24 @@ type valueOwner uint8
 const (
 	cppOwner = 1 << iota
 	jsOwner
+
+	// releasedOwner flags values that were held by the engine on
+	// behalf of Go code, and are now waiting to be destroyed.
+	releasedOwner valueOwner = 0
 )
 
+func (owner valueOwner) String() string {
+	switch owner {
+	case cppOwner:
+		return "cpp"
+	case jsOwner:
+		return "js"
+	case releasedOwner:
+		return "released"
+	}
+	return fmt.Sprintf("valueOwner(%d)", uint8(owner))
+}
+
 // wrapGoValue creates a new GoValue object in C++ land wrapping
 // the Go value contained in the given interface.
 //
@@ -250,8 +620,10 @@ func wrapGoValue(engine *Engine, gvalue interface{}, owner valueOwner) (cvalue u
 		engine: engine,
 		gvalue: gvalue,
 		owner:  owner,
+		stack:  creationStack(),
 	}
 	fold.cvalue = C.newGoValue(unsafe.Pointer(fold), typeInfo(gvalue), parent)
+	attachObservables(gvalue)
 	if prev != nil {
 		// Put new fold first so the single cppOwner, if any, is always the first entry.
 		fold.next = prev
@@ -298,13 +670,106 @@ func hookGoValueTypeNew(cvalue unsafe.Pointer, specp unsafe.Pointer) (foldp unsa
 		gvalue: reflect.New(init.Type().In(0).Elem()).Interface(),
 		cvalue: cvalue,
 		owner:  jsOwner,
+		stack:  creationStack(),
 	}
+	attachObservables(fold.gvalue)
 	typeNew[fold] = true
 	//fmt.Printf("[DEBUG] value alive (type-created): cvalue=%x gvalue=%x/%#v\n", fold.cvalue, addrOf(fold.gvalue), fold.gvalue)
 	stats.valuesAlive(+1)
 	return unsafe.Pointer(fold)
 }
 
+//export hookGoValueSingletonNew
+func hookGoValueSingletonNew(enginep, cvalue, specp unsafe.Pointer) (foldp unsafe.Pointer) {
+	spec := (*TypeSpec)(specp)
+	engine := engines[enginep]
+	if engine == nil {
+		panic("unknown engine pointer; who created the engine?")
+	}
+	var gvalue interface{}
+	if spec.SingletonFactory != nil {
+		result := reflect.ValueOf(spec.SingletonFactory).Call([]reflect.Value{reflect.ValueOf(engine)})[0]
+		if result.IsNil() {
+			panic(fmt.Sprintf("TypeSpec.SingletonFactory returned a nil %s", spec.typ))
+		}
+		gvalue = result.Interface()
+	} else {
+		gvalue = reflect.New(spec.typ.Elem()).Interface()
+	}
+	fold := &valueFold{
+		engine: engine,
+		gvalue: gvalue,
+		cvalue: cvalue,
+		owner:  jsOwner,
+		stack:  creationStack(),
+	}
+	if spec.Init != nil {
+		// Initialization is postponed until the object is fully constructed.
+		fold.init = reflect.ValueOf(spec.Init)
+	}
+	attachObservables(gvalue)
+	appendFold(fold)
+	stats.valuesAlive(+1)
+	return unsafe.Pointer(fold)
+}
+
+//export hookGoValueAttachedNew
+func hookGoValueAttachedNew(enginep, cvalue, attacheep, specp unsafe.Pointer) (foldp unsafe.Pointer) {
+	spec := (*TypeSpec)(specp)
+	engine := engines[enginep]
+	if engine == nil {
+		panic("unknown engine pointer; who created the engine?")
+	}
+	attachee := &Common{engine: engine, addr: attacheep}
+	result := reflect.ValueOf(spec.Attached).Call([]reflect.Value{reflect.ValueOf(Object(attachee))})[0]
+	if result.IsNil() {
+		panic(fmt.Sprintf("TypeSpec.Attached returned a nil %s", result.Type()))
+	}
+	fold := &valueFold{
+		engine: engine,
+		gvalue: result.Interface(),
+		cvalue: cvalue,
+		owner:  jsOwner,
+		stack:  creationStack(),
+	}
+	attachObservables(fold.gvalue)
+	appendFold(fold)
+	stats.valuesAlive(+1)
+	return unsafe.Pointer(fold)
+}
+
+// appendFold appends fold to the list of folds for its Go value
+// in its engine.
+func appendFold(fold *valueFold) {
+	engine := fold.engine
+	if prev := engine.values[fold.gvalue]; prev != nil {
+		for prev.next != nil {
+			prev = prev.next
+		}
+		prev.next = fold
+		fold.prev = prev
+	} else {
+		engine.values[fold.gvalue] = fold
+	}
+}
+
+// moveFoldFirst moves fold to the front of the list of folds for its
+// Go value, where wrapGoValue expects to find the single cppOwner fold.
+func moveFoldFirst(fold *valueFold) {
+	if fold.prev == nil {
+		return
+	}
+	fold.prev.next = fold.next
+	if fold.next != nil {
+		fold.next.prev = fold.prev
+	}
+	head := fold.engine.values[fold.gvalue]
+	fold.prev = nil
+	fold.next = head
+	head.prev = fold
+	fold.engine.values[fold.gvalue] = fold
+}
+
 //export hookGoValueDestroyed
 func hookGoValueDestroyed(enginep unsafe.Pointer, foldp unsafe.Pointer) {
 	fold := (*valueFold)(foldp)
@@ -368,6 +833,10 @@ func hookGoValueReadField(enginep, foldp unsafe.Pointer, reflectIndex, getIndex,
 		field = reflect.ValueOf(fold.gvalue).Method(int(getIndex)).Call(nil)[0]
 	} else {
 		field = deref(reflect.ValueOf(fold.gvalue)).Field(int(reflectIndex))
+		if field.Type() == typeObservable {
+			packDataValue(field.Addr().Interface().(*Observable).Get(), resultdv, fold.engine, jsOwner)
+			return
+		}
 	}
 	field = deref(field)
 
@@ -425,6 +894,11 @@ func hookGoValueWriteField(enginep, foldp unsafe.Pointer, reflectIndex, setIndex
 
 	assign := unpackDataValue(assigndv, fold.engine)
 
+	if !setMethod.IsValid() && field.Type() == typeObservable {
+		field.Addr().Interface().(*Observable).Set(assign)
+		return
+	}
+
 	// TODO Return false to the call site if it fails. That's how Qt seems to handle it internally.
 	err := convertAndSet(field, reflect.ValueOf(assign), setMethod)
 	if err != nil {
@@ -548,7 +1022,7 @@ func printPaintPanic() {
 }
 
 //export hookGoValuePaint
-func hookGoValuePaint(enginep, foldp unsafe.Pointer, reflectIndex C.intptr_t) {
+func hookGoValuePaint(enginep, foldp unsafe.Pointer, reflectIndex C.intptr_t, painterp unsafe.Pointer) {
 	// Besides a convenience this is a workaround for http://golang.org/issue/8588
 	defer printPaintPanic()
 	defer atomic.StoreUintptr(&guiPaintRef, 0)
@@ -562,10 +1036,77 @@ func hookGoValuePaint(enginep, foldp unsafe.Pointer, reflectIndex C.intptr_t) {
 		return
 	}
 
-	painter := &Painter{engine: fold.engine, obj: &Common{fold.cvalue, fold.engine}}
+	painter := &Painter{engine: fold.engine, obj: &Common{fold.cvalue, fold.engine}, painter: painterp, native: true}
+	defer painter.beginNative()
 	v := reflect.ValueOf(fold.gvalue)
 	method := v.Method(int(reflectIndex))
+	start := time.Now()
 	method.Call([]reflect.Value{reflect.ValueOf(painter)})
+	stats.paintCall(time.Since(start))
+}
+
+//export hookGoValueUpdatePaintNode
+func hookGoValueUpdatePaintNode(enginep, foldp unsafe.Pointer, reflectIndex C.intptr_t, itemp, rootp unsafe.Pointer) {
+	defer printPaintPanic()
+	defer atomic.StoreUintptr(&guiPaintRef, 0)
+
+	// The main GUI thread is blocked while the scene graph is updated,
+	// so this is handled as a painting.
+	atomic.StoreUintptr(&guiPaintRef, cdata.Ref())
+
+	fold := ensureEngine(enginep, foldp)
+	if fold.init.IsValid() {
+		// The init function runs in the main GUI thread, and another
+		// update is requested once it's done. See initGoType.
+		return
+	}
+
+	sg := &SceneGraph{obj: &Common{fold.cvalue, fold.engine}, item: itemp, root: rootp}
+	v := reflect.ValueOf(fold.gvalue)
+	method := v.Method(int(reflectIndex))
+	start := time.Now()
+	method.Call([]reflect.Value{reflect.ValueOf(sg)})
+	stats.paintCall(time.Since(start))
+}
+
+//export hookGoValueInputEvent
+func hookGoValueInputEvent(enginep, foldp unsafe.Pointer, reflectIndex C.int, cevent *C.GoInputEvent) {
+	fold := ensureEngine(enginep, foldp)
+	event, input := newInputEvent(cevent)
+	method := reflect.ValueOf(fold.gvalue).Method(int(reflectIndex))
+	method.Call([]reflect.Value{reflect.ValueOf(event)})
+	if input != nil {
+		cevent.accepted = cbool(input.accepted)
+	}
+}
+
+//export hookGoValueSpecComplete
+func hookGoValueSpecComplete(enginep, foldp, specp unsafe.Pointer) {
+	fold := ensureEngine(enginep, foldp)
+	obj := &Common{engine: fold.engine, addr: fold.cvalue}
+	complete := reflect.ValueOf((*TypeSpec)(specp).Complete)
+	complete.Call([]reflect.Value{reflect.ValueOf(fold.gvalue), reflect.ValueOf(obj)})
+}
+
+//export hookGoValueLifecycle
+func hookGoValueLifecycle(enginep, foldp unsafe.Pointer, reflectIndex C.int) {
+	fold := ensureEngine(enginep, foldp)
+	reflect.ValueOf(fold.gvalue).Method(int(reflectIndex)).Call(nil)
+}
+
+//export hookGoValueGeometryChanged
+func hookGoValueGeometryChanged(enginep, foldp unsafe.Pointer, reflectIndex C.int, newGeometry, oldGeometry *C.double) {
+	fold := ensureEngine(enginep, foldp)
+	method := reflect.ValueOf(fold.gvalue).Method(int(reflectIndex))
+	method.Call([]reflect.Value{reflect.ValueOf(newRect(newGeometry)), reflect.ValueOf(newRect(oldGeometry))})
+}
+
+//export hookGoValueItemChanged
+func hookGoValueItemChanged(enginep, foldp unsafe.Pointer, reflectIndex C.int, change C.int, value *C.DataValue) {
+	fold := ensureEngine(enginep, foldp)
+	itemChange := ItemChange{Kind: ItemChangeKind(change), Value: unpackDataValue(value, fold.engine)}
+	method := reflect.ValueOf(fold.gvalue).Method(int(reflectIndex))
+	method.Call([]reflect.Value{reflect.ValueOf(itemChange)})
 }
 
 func ensureEngine(enginep, foldp unsafe.Pointer) *valueFold {
@@ -585,16 +1126,7 @@ func ensureEngine(enginep, foldp unsafe.Pointer) *valueFold {
 		panic("unknown engine pointer; who created the engine?")
 	}
 	fold.engine = engine
-	prev := engine.values[fold.gvalue]
-	if prev != nil {
-		for prev.next != nil {
-			prev = prev.next
-		}
-		prev.next = fold
-		fold.prev = prev
-	} else {
-		engine.values[fold.gvalue] = fold
-	}
+	appendFold(fold)
 	before := len(typeNew)
 	delete(typeNew, fold)
 	if len(typeNew) == before {
@@ -613,14 +1145,15 @@ func initGoType(fold *valueFold) {
 }
 
 func _initGoType(fold *valueFold, schedulePaint bool) {
-	if !fold.init.IsValid() {
-		return
-	}
 	// TODO Would be good to preserve identity on the Go side. See unpackDataValue as well.
 	obj := &Common{engine: fold.engine, addr: fold.cvalue}
-	fold.init.Call([]reflect.Value{reflect.ValueOf(fold.gvalue), reflect.ValueOf(obj)})
-	fold.init = reflect.Value{}
+	if fold.init.IsValid() {
+		fold.init.Call([]reflect.Value{reflect.ValueOf(fold.gvalue), reflect.ValueOf(obj)})
+		fold.init = reflect.Value{}
+	}
 	if schedulePaint {
+		// The painting that scheduled this was skipped. Paint again even
+		// if init ran meanwhile in the GUI thread for another reason.
 		obj.Call("update")
 	}
 }
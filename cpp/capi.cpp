@@ -43,25 +43,131 @@ void panicf(const char *format, ...)
     hookPanic(local_strdup(ba.constData()));
 }
 
-void newGuiApplication()
+// Qt holds on to argc and argv for the life time of the application,
+// and may also reorder argv while parsing its own arguments.
+static int appArgc;
+static char **appArgv;
+static char **appArgs;
+
+static void freeAppArgs()
+{
+    if (appArgs) {
+        for (int i = 0; appArgs[i]; i++) {
+            free(appArgs[i]);
+        }
+        free(appArgs);
+        free(appArgv);
+        appArgs = 0;
+        appArgv = 0;
+    }
+}
+
+error *newGuiApplication(ApplicationOptions *options)
+{
+    // Check the options before changing any attribute, so that a failed
+    // run does not leave attributes behind.
+    switch (options->openGL) {
+    case OpenGLDefault:
+        break;
+#if QT_VERSION >= QT_VERSION_CHECK(5, 3, 0)
+    case OpenGLDesktop:
+    case OpenGLES:
+        break;
+#endif
+#if QT_VERSION >= QT_VERSION_CHECK(5, 4, 0)
+    case OpenGLSoftware:
+        break;
+#endif
+    default:
+        return errorf("OpenGL implementation %d is not supported by Qt %s", options->openGL, qVersion());
+    }
+#if QT_VERSION < QT_VERSION_CHECK(5, 6, 0)
+    if (options->highDPIScaling) {
+        return errorf("high DPI scaling requires Qt 5.6 or later; running with Qt %s", qVersion());
+    }
+#endif
+#if QT_VERSION < QT_VERSION_CHECK(5, 4, 0)
+    if (options->shareOpenGLContexts) {
+        return errorf("sharing OpenGL contexts requires Qt 5.4 or later; running with Qt %s", qVersion());
+    }
+#endif
+
+    // Attributes are process-wide and outlive the application, so every
+    // one of them is set on each run to avoid leaking a previous run's options.
+#if QT_VERSION >= QT_VERSION_CHECK(5, 3, 0)
+    QCoreApplication::setAttribute(Qt::AA_UseDesktopOpenGL, options->openGL == OpenGLDesktop);
+    QCoreApplication::setAttribute(Qt::AA_UseOpenGLES, options->openGL == OpenGLES);
+#endif
+#if QT_VERSION >= QT_VERSION_CHECK(5, 4, 0)
+    QCoreApplication::setAttribute(Qt::AA_UseSoftwareOpenGL, options->openGL == OpenGLSoftware);
+    QCoreApplication::setAttribute(Qt::AA_ShareOpenGLContexts, options->shareOpenGLContexts);
+#endif
+#if QT_VERSION >= QT_VERSION_CHECK(5, 6, 0)
+    QCoreApplication::setAttribute(Qt::AA_EnableHighDpiScaling, options->highDPIScaling);
+#endif
+
+    freeAppArgs();
+    appArgc = options->argc;
+    appArgs = (char **)malloc((appArgc + 1) * sizeof(char *));
+    appArgv = (char **)malloc((appArgc + 1) * sizeof(char *));
+    const char *arg = options->args;
+    const char *argsEnd = options->args + options->argsLen;
+    for (int i = 0; i < appArgc; i++) {
+        const char *argEnd = (const char *)memchr(arg, 0, argsEnd - arg);
+        size_t len = argEnd ? argEnd - arg : argsEnd - arg;
+        appArgs[i] = (char *)malloc(len + 1);
+        memcpy(appArgs[i], arg, len);
+        appArgs[i][len] = 0;
+        appArgv[i] = appArgs[i];
+        arg += len + 1;
+    }
+    appArgs[appArgc] = 0;
+    appArgv[appArgc] = 0;
+
+    if (options->guiApplication) {
+        new QGuiApplication(appArgc, appArgv);
+    } else {
+        new QApplication(appArgc, appArgv);
+    }
+
+    // Like attributes, these outlive the application and are set on every run.
+    QCoreApplication::setOrganizationName(QString::fromUtf8(options->organizationName, options->organizationNameLen));
+    QCoreApplication::setOrganizationDomain(QString::fromUtf8(options->organizationDomain, options->organizationDomainLen));
+    QCoreApplication::setApplicationName(QString::fromUtf8(options->applicationName, options->applicationNameLen));
+    QCoreApplication::setApplicationVersion(QString::fromUtf8(options->applicationVersion, options->applicationVersionLen));
+
+    // By default the event loop should never die on its own.
+    QGuiApplication::setQuitOnLastWindowClosed(options->quitOnLastWindowClosed);
+
+    QObject::connect(QCoreApplication::instance(), &QCoreApplication::aboutToQuit, [=]() {
+        hookApplicationAboutToQuit();
+    });
+    return 0;
+}
+
+int applicationExec()
 {
-    static char empty[1] = {0};
-    static char *argv[] = {empty, 0};
-    static int argc = 1;
-    new QApplication(argc, argv);
+    return qApp->exec();
+}
 
-    // The event loop should never die.
-    qApp->setQuitOnLastWindowClosed(false);
+void applicationExit(int code)
+{
+    qApp->exit(code);
 }
 
-void applicationExec()
+void applicationSetQuitOnLastWindowClosed(int quit)
 {
-    qApp->exec();
+    QGuiApplication::setQuitOnLastWindowClosed(quit);
 }
 
-void applicationExit()
+void applicationDestroy()
 {
-    qApp->exit(0);
+    // Objects scheduled for deletion would otherwise leak, as deferred
+    // deletions are only processed by a running event loop.
+    QCoreApplication::sendPostedEvents(0, QEvent::DeferredDelete);
+    idleTimerStop();
+    delete QCoreApplication::instance();
+    freeAppArgs();
 }
 
 void applicationFlushAll()
@@ -113,6 +219,17 @@ void engineSetOwnershipJS(QQmlEngine_ *engine, QObject_ *object)
     qengine->setObjectOwnership(qobject, QQmlEngine::JavaScriptOwnership);
 }
 
+int engineObjectOwnership(QQmlEngine_ *engine, QObject_ *object)
+{
+    QQmlEngine *qengine = reinterpret_cast<QQmlEngine *>(engine);
+    QObject *qobject = reinterpret_cast<QObject *>(object);
+
+    if (qengine->objectOwnership(qobject) == QQmlEngine::JavaScriptOwnership) {
+        return 1;
+    }
+    return 0;
+}
+
 QQmlComponent_ *newComponent(QQmlEngine_ *engine, QObject_ *parent)
 {
     QQmlEngine *qengine = reinterpret_cast<QQmlEngine *>(engine);
@@ -244,14 +361,25 @@ uintptr_t windowPlatformId(QQuickWindow_ *win)
     return reinterpret_cast<QQuickWindow *>(win)->winId();
 }
 
+static QHash<QQuickWindow_ *, QMetaObject::Connection> windowHiddenConnections;
+
 void windowConnectHidden(QQuickWindow_ *win)
 {
+    if (windowHiddenConnections.contains(win)) {
+        return;
+    }
     QQuickWindow *qwin = reinterpret_cast<QQuickWindow *>(win);
-    QObject::connect(qwin, &QWindow::visibleChanged, [=](bool visible){
+    windowHiddenConnections.insert(win, QObject::connect(qwin, &QWindow::visibleChanged, [=](bool visible){
         if (!visible) {
+            windowDisconnectHidden(win);
             hookWindowHidden(win);
         }
-    });
+    }));
+}
+
+void windowDisconnectHidden(QQuickWindow_ *win)
+{
+    QObject::disconnect(windowHiddenConnections.take(win));
 }
 
 QObject_ *windowRootObject(QQuickWindow_ *win)
@@ -492,32 +620,103 @@ void objectSetParent(QObject_ *object, QObject_ *parent)
     qobject->setParent(qparent);
 }
 
-error *objectConnect(QObject_ *object, const char *signal, int signalLen, QQmlEngine_ *engine, void *func, int argsLen)
+QObject_ *objectParent(QObject_ *object)
+{
+    return reinterpret_cast<QObject *>(object)->parent();
+}
+
+// signalHasObjectParams returns whether any of the first argsLen parameters
+// of method is an object, which is only valid while the signal is handled.
+static bool signalHasObjectParams(const QMetaMethod &method, int argsLen)
+{
+    for (int i = 0; i < argsLen; i++) {
+        int paramType = method.parameterType(i);
+        // Unknown types are delivered wrapped in a temporary PlainObject.
+        if (paramType == QMetaType::UnknownType || (QMetaType::typeFlags(paramType) & QMetaType::PointerToQObject)) {
+            return true;
+        }
+    }
+    return false;
+}
+
+error *objectConnect(QObject_ *object, const char *signal, int signalLen, QQmlEngine_ *engine, void *func, int argsLen, int valuesOnly, QObject_ **connector, char **name)
 {
     QObject *qobject = reinterpret_cast<QObject *>(object);
     QQmlEngine *qengine = reinterpret_cast<QQmlEngine *>(engine);
     QByteArray qsignal(signal, signalLen);
 
+    // A full signature such as "clicked(QQuickMouseEvent*)" selects a specific overload.
+    bool bySignature = qsignal.contains('(');
+    if (bySignature) {
+        qsignal = QMetaObject::normalizedSignature(qsignal.constData());
+    }
+
+    // A negative argsLen requests all parameters, with at least -argsLen-1 of them.
+    int minArgsLen = argsLen < 0 ? -argsLen-1 : argsLen;
+
+    QByteArray tooFew, objectParams;
     const QMetaObject *meta = qobject->metaObject();
     // Walk backwards so descendants have priority.
     for (int i = meta->methodCount()-1; i >= 0; i--) {
             QMetaMethod method = meta->method(i);
-            if (method.methodType() == QMetaMethod::Signal) {
-                QByteArray name = method.name();
-                if (name.length() == signalLen && qstrncmp(name.constData(), signal, signalLen) == 0) {
-                    if (method.parameterCount() < argsLen) {
-                        // TODO Might continue looking to see if a different signal has the same name and enough arguments.
-                        return errorf("signal \"%s\" has too few parameters for provided function", name.constData());
-                    }
-                    Connector *connector = new Connector(qobject, method, qengine, func, argsLen);
-                    const QMetaObject *connmeta = connector->metaObject();
-                    QObject::connect(qobject, method, connector, connmeta->method(connmeta->methodOffset()));
-                    return 0;
+            if (method.methodType() != QMetaMethod::Signal) {
+                continue;
+            }
+            if (bySignature ? method.methodSignature() != qsignal : method.name() != qsignal) {
+                continue;
+            }
+            if (method.parameterCount() < minArgsLen) {
+                // Keep looking, as an overload may have enough parameters.
+                if (tooFew.isEmpty()) {
+                    tooFew = method.name();
+                }
+                continue;
+            }
+            int connArgsLen = argsLen < 0 ? method.parameterCount() : argsLen;
+            if (valuesOnly && signalHasObjectParams(method, connArgsLen)) {
+                if (objectParams.isEmpty()) {
+                    objectParams = method.name();
                 }
+                continue;
             }
+            Connector *conn = new Connector(qobject, method, qengine, func, connArgsLen);
+            const QMetaObject *connmeta = conn->metaObject();
+            QObject::connect(qobject, method, conn, connmeta->method(connmeta->methodOffset()));
+            *connector = conn;
+            *name = local_strdup(method.name().constData());
+            return 0;
     }
-    // Cannot use constData here as the byte array is not null-terminated.
-    return errorf("object does not expose a \"%s\" signal", qsignal.data());
+    if (!objectParams.isEmpty()) {
+        return errorf("signal \"%s\" has object parameters that cannot be delivered to a channel", objectParams.constData());
+    }
+    if (!tooFew.isEmpty()) {
+        return errorf("signal \"%s\" has too few parameters for provided function", tooFew.constData());
+    }
+    return errorf("object does not expose a \"%s\" signal", qsignal.constData());
+}
+
+error *objectConnectNotify(QObject_ *object, const char *property, int propertyLen, QQmlEngine_ *engine, void *func, QObject_ **connector, char **signal)
+{
+    QObject *qobject = reinterpret_cast<QObject *>(object);
+    QQmlEngine *qengine = reinterpret_cast<QQmlEngine *>(engine);
+    QByteArray qproperty(property, propertyLen);
+
+    const QMetaObject *meta = qobject->metaObject();
+    int index = meta->indexOfProperty(qproperty.constData());
+    if (index == -1) {
+        return errorf("object does not have a \"%s\" property", qproperty.constData());
+    }
+    QMetaProperty metaProperty = meta->property(index);
+    if (!metaProperty.hasNotifySignal()) {
+        return errorf("property \"%s\" has no notify signal", qproperty.constData());
+    }
+    QMetaMethod method = metaProperty.notifySignal();
+    Connector *conn = new Connector(qobject, method, qengine, func, 0);
+    const QMetaObject *connmeta = conn->metaObject();
+    QObject::connect(qobject, method, conn, connmeta->method(connmeta->methodOffset()));
+    *connector = conn;
+    *signal = local_strdup(method.name().constData());
+    return 0;
 }
 
 QQmlContext_ *objectContext(QObject_ *object)
@@ -556,6 +755,11 @@ error *objectGoAddr(QObject_ *object, GoAddr **addr)
         *addr = goPaintedValue->addr;
         return 0;
     }
+    GoQuickItem *goQuickItem = dynamic_cast<GoQuickItem *>(qobject);
+    if (goQuickItem) {
+        *addr = goQuickItem->addr;
+        return 0;
+    }
     return errorf("QML object is not backed by a Go value");
 }
 
@@ -577,6 +781,9 @@ GoValue_ *newGoValue(GoAddr *addr, GoTypeInfo *typeInfo, QObject_ *parent)
     if (typeInfo->paint) {
         return new GoPaintedValue(addr, typeInfo, qparent);
     }
+    if (typeInfo->updatePaintNode) {
+        return new GoQuickItem(addr, typeInfo, qparent);
+    }
     return new GoValue(addr, typeInfo, qparent);
 }
 
@@ -587,6 +794,8 @@ void goValueActivate(GoValue_ *value, GoTypeInfo *typeInfo, int addrOffset)
         if (fieldInfo->addrOffset == addrOffset) {
             if (typeInfo->paint) {
                 static_cast<GoPaintedValue *>(value)->activate(fieldInfo->metaIndex);
+            } else if (typeInfo->updatePaintNode) {
+                static_cast<GoQuickItem *>(value)->activate(fieldInfo->metaIndex);
             } else {
                 static_cast<GoValue *>(value)->activate(fieldInfo->metaIndex);
             }
@@ -758,6 +967,12 @@ void packDataValue(QVariant_ *var, DataValue *value)
                 *(void **)(value->data) = goPaintedValue->addr;
                 break;
             }
+            GoQuickItem *goQuickItem = dynamic_cast<GoQuickItem *>(qobject);
+            if (goQuickItem) {
+                value->dataType = DTGoAddr;
+                *(void **)(value->data) = goQuickItem->addr;
+                break;
+            }
             value->dataType = DTObject;
             *(void **)(value->data) = qobject;
             break;
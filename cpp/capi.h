@@ -25,8 +25,11 @@ typedef void QQmlComponent_;
 typedef void QQmlListProperty_;
 typedef void QQuickWindow_;
 typedef void QQuickView_;
+typedef void QQuickItem_;
+typedef void QSGNode_;
 typedef void QMessageLogContext_;
 typedef void QImage_;
+typedef void QPainter_;
 typedef void GoValue_;
 typedef void GoAddr;
 typedef void GoTypeSpec_;
@@ -68,6 +71,25 @@ typedef struct {
     int len;
 } DataValue;
 
+typedef enum {
+    PathMoveTo,
+    PathLineTo,
+    PathQuadTo,
+    PathCubicTo,
+    PathArcTo,
+    PathClose,
+    PathAddRect,
+    PathAddEllipse,
+    PathAddRoundedRect
+} PathOp;
+
+typedef enum {
+    BrushNone,
+    BrushColor,
+    BrushLinearGradient,
+    BrushRadialGradient
+} BrushKind;
+
 typedef struct {
     char *memberName; // points to memberNames
     DataType memberType;
@@ -76,22 +98,95 @@ typedef struct {
     int reflectSetIndex;
     int metaIndex;
     int addrOffset;
+    int readOnly;
+    int revision;
+    char *notifySignal; // property change signal name, or NULL for the default
     char *methodSignature;
     char *resultSignature;
     int numIn;
     int numOut;
 } GoMemberInfo;
 
+typedef struct {
+    char *enumName;
+    char *keyNames; // "Key1\0Key2\0..."
+    int *values;
+    int keysLen;
+} GoEnumInfo;
+
+typedef enum {
+    EventMousePress,
+    EventMouseRelease,
+    EventMouseMove,
+    EventMouseDoubleClick,
+    EventHoverEnter,
+    EventHoverMove,
+    EventHoverLeave,
+    EventWheel,
+    EventTouch,
+    EventKeyPress,
+    EventKeyRelease,
+    EventFocusIn,
+    EventFocusOut,
+
+    EventKindsLen
+} InputEventKind;
+
+typedef struct {
+    int id;
+    int state;
+    double x;
+    double y;
+    double pressure;
+} GoTouchPoint;
+
+typedef struct {
+    int kind;
+    int accepted;
+    double x;
+    double y;
+    double sceneX;
+    double sceneY;
+    int button;
+    int buttons;
+    int modifiers;
+    double angleDeltaX; // wheel
+    double angleDeltaY;
+    int key;
+    char *text;
+    int textLen;
+    int autoRepeat;
+    GoTouchPoint *touchPoints;
+    int touchPointsLen;
+    int reason; // focus
+} GoInputEvent;
+
+typedef enum {
+    HookClassBegin,
+    HookComponentComplete,
+    HookGeometryChanged,
+    HookItemChanged,
+
+    HookMethodsLen
+} HookMethodKind;
+
 typedef struct {
     char *typeName;
     GoMemberInfo *fields;
     GoMemberInfo *methods;
     GoMemberInfo *members; // fields + methods
     GoMemberInfo *paint;   // in methods too
+    GoMemberInfo *updatePaintNode; // in methods too
     int fieldsLen;
     int methodsLen;
     int membersLen;
     char *memberNames;
+    GoEnumInfo *enums;
+    int enumsLen;
+    int eventMethods[EventKindsLen]; // reflect index of input event handlers, or -1
+    int hookMethods[HookMethodsLen]; // reflect index of lifecycle hooks, or -1
+    char *defaultProperty; // set per registered type, or NULL
+    int specComplete;      // whether the registered TypeSpec has a Complete function
 
     QMetaObject_ *metaObject;
 } GoTypeInfo;
@@ -105,13 +200,42 @@ typedef struct {
     int line;
 } LogMessage;
 
-void newGuiApplication();
-void applicationExec();
-void applicationExit();
+typedef enum {
+    OpenGLDefault,
+    OpenGLDesktop,
+    OpenGLES,
+    OpenGLSoftware
+} OpenGLImpl;
+
+typedef struct {
+    int guiApplication;
+    int argc;
+    const char *args; // argc arguments separated by NUL
+    int argsLen;
+    const char *organizationName;
+    int organizationNameLen;
+    const char *organizationDomain;
+    int organizationDomainLen;
+    const char *applicationName;
+    int applicationNameLen;
+    const char *applicationVersion;
+    int applicationVersionLen;
+    int highDPIScaling;
+    OpenGLImpl openGL;
+    int shareOpenGLContexts;
+    int quitOnLastWindowClosed;
+} ApplicationOptions;
+
+error *newGuiApplication(ApplicationOptions *options);
+int applicationExec();
+void applicationExit(int code);
 void applicationFlushAll();
+void applicationSetQuitOnLastWindowClosed(int quit);
+void applicationDestroy();
 
 void idleTimerInit(int32_t *guiIdleRun);
 void idleTimerStart();
+void idleTimerStop();
 
 void *currentThread();
 void *appThread();
@@ -120,6 +244,7 @@ QQmlEngine_ *newEngine(QObject_ *parent);
 QQmlContext_ *engineRootContext(QQmlEngine_ *engine);
 void engineSetOwnershipCPP(QQmlEngine_ *engine, QObject_ *object);
 void engineSetOwnershipJS(QQmlEngine_ *engine, QObject_ *object);
+int engineObjectOwnership(QQmlEngine_ *engine, QObject_ *object);
 void engineSetContextForObject(QQmlEngine_ *engine, QObject_ *object);
 void engineAddImageProvider(QQmlEngine_ *engine, QString_ *providerId, void *imageFunc);
 
@@ -134,13 +259,15 @@ const char *objectTypeName(QObject_ *object);
 int objectGetProperty(QObject_ *object, const char *name, DataValue *result);
 error *objectSetProperty(QObject_ *object, const char *name, DataValue *value);
 void objectSetParent(QObject_ *object, QObject_ *parent);
+QObject_ *objectParent(QObject_ *object);
 error *objectInvoke(QObject_ *object, const char *method, int methodLen, DataValue *result, DataValue *params, int paramsLen);
 void objectFindChild(QObject_ *object, QString_ *name, DataValue *result);
 QQmlContext_ *objectContext(QObject_ *object);
 int objectIsComponent(QObject_ *object);
 int objectIsWindow(QObject_ *object);
 int objectIsView(QObject_ *object);
-error *objectConnect(QObject_ *object, const char *signal, int signalLen, QQmlEngine_ *engine, void *func, int argsLen);
+error *objectConnect(QObject_ *object, const char *signal, int signalLen, QQmlEngine_ *engine, void *func, int argsLen, int valuesOnly, QObject_ **connector, char **name);
+error *objectConnectNotify(QObject_ *object, const char *property, int propertyLen, QQmlEngine_ *engine, void *func, QObject_ **connector, char **signal);
 error *objectGoAddr(QObject_ *object, GoAddr **addr);
 
 QQmlComponent_ *newComponent(QQmlEngine_ *engine, QObject_ *parent);
@@ -154,6 +281,7 @@ void windowShow(QQuickWindow_ *win);
 void windowHide(QQuickWindow_ *win);
 uintptr_t windowPlatformId(QQuickWindow_ *win);
 void windowConnectHidden(QQuickWindow_ *win);
+void windowDisconnectHidden(QQuickWindow_ *win);
 QObject_ *windowRootObject(QQuickWindow_ *win);
 QImage_ *windowGrabWindow(QQuickWindow_ *win);
 
@@ -176,22 +304,67 @@ QVariantList_ *newVariantList(DataValue *list, int len);
 
 QQmlListProperty_ *newListProperty(GoAddr *addr, intptr_t reflectIndex, intptr_t setIndex);
 
-int registerType(char *location, int major, int minor, char *name, GoTypeInfo *typeInfo, GoTypeSpec_ *spec);
-int registerSingleton(char *location, int major, int minor, char *name, GoTypeInfo *typeInfo, GoTypeSpec_ *spec);
+int registerType(char *location, int major, int minor, char *name, GoTypeInfo *typeInfo, GoTypeSpec_ *spec, int revision, char *uncreatable, GoTypeInfo *attachedInfo);
+int registerSingleton(char *location, int major, int minor, char *name, GoTypeInfo *typeInfo, GoTypeSpec_ *spec, int revision);
+int registerModule(char *location, int major, int minor);
+error *engineSingleton(QQmlEngine_ *engine, int typeId, QObject_ **singleton);
+
+int sgNodeChildCount(QSGNode_ *node);
+void sgNodeRemoveAll(QSGNode_ *node);
+QSGNode_ *sgNodeAddGeometry(QSGNode_ *parent, int mode, float *vertices, int verticesLen, unsigned int color);
+void sgGeometrySetVertices(QSGNode_ *node, int mode, float *vertices, int verticesLen);
+void sgGeometrySetColor(QSGNode_ *node, unsigned int color);
+QSGNode_ *sgNodeAddTexture(QSGNode_ *parent, QQuickItem_ *item, QImage_ *image, double x, double y, double width, double height);
+void sgTextureSetImage(QSGNode_ *node, QQuickItem_ *item, QImage_ *image);
+void sgTextureSetRect(QSGNode_ *node, double x, double y, double width, double height);
+
+void painterBeginNativePainting(QPainter_ *painter);
+void painterEndNativePainting(QPainter_ *painter);
+void painterSave(QPainter_ *painter);
+void painterRestore(QPainter_ *painter);
+void painterSetRenderHint(QPainter_ *painter, int hint, int on);
+void painterSetOpacity(QPainter_ *painter, double opacity);
+void painterSetPen(QPainter_ *painter, unsigned int color, double width, int style, int cap, int join);
+void painterSetBrush(QPainter_ *painter, int kind, unsigned int color, double *coords, double *stopPositions, unsigned int *stopColors, int stopsLen);
+void painterSetFont(QPainter_ *painter, char *family, int familyLen, double pointSize, int pixelSize, int bold, int italic);
+void painterTranslate(QPainter_ *painter, double dx, double dy);
+void painterRotate(QPainter_ *painter, double angle);
+void painterScale(QPainter_ *painter, double sx, double sy);
+void painterResetTransform(QPainter_ *painter);
+void painterSetClipRect(QPainter_ *painter, double x, double y, double width, double height);
+void painterSetClipPath(QPainter_ *painter, int *ops, int opsLen, double *coords);
+void painterSetClipping(QPainter_ *painter, int enabled);
+void painterDrawLine(QPainter_ *painter, double x1, double y1, double x2, double y2);
+void painterDrawRect(QPainter_ *painter, double x, double y, double width, double height, double xRadius, double yRadius);
+void painterDrawEllipse(QPainter_ *painter, double x, double y, double width, double height);
+void painterDrawPath(QPainter_ *painter, int *ops, int opsLen, double *coords);
+void painterDrawText(QPainter_ *painter, double x, double y, char *text, int textLen);
+void painterDrawTextRect(QPainter_ *painter, double x, double y, double width, double height, int flags, char *text, int textLen);
+void painterTextSize(QPainter_ *painter, char *text, int textLen, double *width, double *height);
+void painterDrawImage(QPainter_ *painter, double x, double y, double width, double height, QImage_ *image);
 
 void installLogHandler();
 
 void hookIdleTimer();
+void hookApplicationAboutToQuit();
 void hookLogHandler(LogMessage *message);
 void hookGoValueReadField(QQmlEngine_ *engine, GoAddr *addr, int memberIndex, int getIndex, int setIndex, DataValue *result);
 void hookGoValueWriteField(QQmlEngine_ *engine, GoAddr *addr, int memberIndex, int setIndex, DataValue *assign);
 void hookGoValueCallMethod(QQmlEngine_ *engine, GoAddr *addr, int memberIndex, DataValue *result);
 void hookGoValueDestroyed(QQmlEngine_ *engine, GoAddr *addr);
-void hookGoValuePaint(QQmlEngine_ *engine, GoAddr *addr, intptr_t reflextIndex);
+void hookGoValuePaint(QQmlEngine_ *engine, GoAddr *addr, intptr_t reflextIndex, QPainter_ *painter);
 QImage_ *hookRequestImage(void *imageFunc, char *id, int idLen, int width, int height);
 GoAddr *hookGoValueTypeNew(GoValue_ *value, GoTypeSpec_ *spec);
+void hookGoValueUpdatePaintNode(QQmlEngine_ *engine, GoAddr *addr, intptr_t reflectIndex, QQuickItem_ *item, QSGNode_ *root);
+void hookGoValueInputEvent(QQmlEngine_ *engine, GoAddr *addr, int reflectIndex, GoInputEvent *event);
+void hookGoValueSpecComplete(QQmlEngine_ *engine, GoAddr *addr, GoTypeSpec_ *spec);
+void hookGoValueLifecycle(QQmlEngine_ *engine, GoAddr *addr, int reflectIndex);
+void hookGoValueGeometryChanged(QQmlEngine_ *engine, GoAddr *addr, int reflectIndex, double *newGeometry, double *oldGeometry);
+void hookGoValueItemChanged(QQmlEngine_ *engine, GoAddr *addr, int reflectIndex, int change, DataValue *value);
+GoAddr *hookGoValueSingletonNew(QQmlEngine_ *engine, GoValue_ *value, GoTypeSpec_ *spec);
+GoAddr *hookGoValueAttachedNew(QQmlEngine_ *engine, GoValue_ *value, QObject_ *attachee, GoTypeSpec_ *spec);
 void hookWindowHidden(QObject_ *addr);
-void hookSignalCall(QQmlEngine_ *engine, void *func, DataValue *params);
+void hookSignalCall(QQmlEngine_ *engine, void *func, DataValue *params, int paramsLen);
 void hookSignalDisconnect(void *func);
 void hookPanic(char *message);
 int hookListPropertyCount(GoAddr *addr, intptr_t reflectIndex, intptr_t setIndex);
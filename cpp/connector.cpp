@@ -1,4 +1,5 @@
 #include <QObject>
+#include <QVarLengthArray>
 
 #include "connector.h"
 #include "capi.h"
@@ -16,7 +17,7 @@ void Connector::invoke()
 int Connector::qt_metacall(QMetaObject::Call c, int idx, void **a)
 {
     if (c == QMetaObject::InvokeMetaMethod && idx == metaObject()->methodOffset()) {
-        DataValue args[MaxParams];
+        QVarLengthArray<DataValue, MaxParams> args(argsLen);
         QObject *plain = NULL;
         for (int i = 0; i < argsLen; i++) {
             int paramType = method.parameterType(i);
@@ -34,7 +35,7 @@ int Connector::qt_metacall(QMetaObject::Call c, int idx, void **a)
                 packDataValue(&var, &args[i]);
             }
         }
-        hookSignalCall(engine, func, args);
+        hookSignalCall(engine, func, args.data(), argsLen);
         if (plain != NULL) {
                 delete plain;
         }
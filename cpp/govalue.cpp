@@ -138,6 +138,157 @@ void GoValue::activate(int propIndex)
     valueMeta->activatePropIndex(propIndex);
 }
 
+// setupInputEvents enables the delivery to item of the input events
+// handled by its Go type.
+static void setupInputEvents(QQuickItem *item, GoTypeInfo *typeInfo)
+{
+    int *methods = typeInfo->eventMethods;
+    if (methods[EventMousePress] >= 0 || methods[EventMouseRelease] >= 0 ||
+        methods[EventMouseMove] >= 0 || methods[EventMouseDoubleClick] >= 0) {
+        item->setAcceptedMouseButtons(Qt::AllButtons);
+    }
+    if (methods[EventHoverEnter] >= 0 || methods[EventHoverMove] >= 0 || methods[EventHoverLeave] >= 0) {
+        item->setAcceptHoverEvents(true);
+    }
+#if QT_VERSION >= QT_VERSION_CHECK(5, 10, 0)
+    if (methods[EventTouch] >= 0) {
+        item->setAcceptTouchEvents(true);
+    }
+#endif
+}
+
+// dispatchInputEvent hands event over to the Go method handling it, if any,
+// and reports whether the event was consumed. Focus events are never
+// consumed, so that the item itself may still observe them, and neither
+// are key events ignored by the Go method, so that Keys handlers declared
+// in QML for the item may still handle them.
+static bool dispatchInputEvent(QQuickItem *item, GoAddr *addr, GoTypeInfo *typeInfo, QEvent *event)
+{
+    int kind;
+    switch (event->type()) {
+    case QEvent::MouseButtonPress:    kind = EventMousePress; break;
+    case QEvent::MouseButtonRelease:  kind = EventMouseRelease; break;
+    case QEvent::MouseMove:           kind = EventMouseMove; break;
+    case QEvent::MouseButtonDblClick: kind = EventMouseDoubleClick; break;
+    case QEvent::HoverEnter:          kind = EventHoverEnter; break;
+    case QEvent::HoverMove:           kind = EventHoverMove; break;
+    case QEvent::HoverLeave:          kind = EventHoverLeave; break;
+    case QEvent::Wheel:               kind = EventWheel; break;
+    case QEvent::TouchBegin:
+    case QEvent::TouchUpdate:
+    case QEvent::TouchEnd:
+    case QEvent::TouchCancel:         kind = EventTouch; break;
+    case QEvent::KeyPress:            kind = EventKeyPress; break;
+    case QEvent::KeyRelease:          kind = EventKeyRelease; break;
+    case QEvent::FocusIn:             kind = EventFocusIn; break;
+    case QEvent::FocusOut:            kind = EventFocusOut; break;
+    default:
+        return false;
+    }
+    int reflectIndex = typeInfo->eventMethods[kind];
+    if (reflectIndex < 0) {
+        return false;
+    }
+
+    GoInputEvent goEvent;
+    memset(&goEvent, 0, sizeof(goEvent));
+    goEvent.kind = kind;
+    goEvent.accepted = 1;
+
+    QByteArray text;
+    QVector<GoTouchPoint> touchPoints;
+    switch (kind) {
+    case EventMousePress:
+    case EventMouseRelease:
+    case EventMouseMove:
+    case EventMouseDoubleClick:
+        {
+            QMouseEvent *mouseEvent = static_cast<QMouseEvent *>(event);
+            goEvent.x = mouseEvent->localPos().x();
+            goEvent.y = mouseEvent->localPos().y();
+            goEvent.sceneX = mouseEvent->windowPos().x();
+            goEvent.sceneY = mouseEvent->windowPos().y();
+            goEvent.button = mouseEvent->button();
+            goEvent.buttons = mouseEvent->buttons();
+            goEvent.modifiers = mouseEvent->modifiers();
+            break;
+        }
+    case EventHoverEnter:
+    case EventHoverMove:
+    case EventHoverLeave:
+        {
+            QHoverEvent *hoverEvent = static_cast<QHoverEvent *>(event);
+            goEvent.x = hoverEvent->posF().x();
+            goEvent.y = hoverEvent->posF().y();
+            QPointF scenePos = item->mapToScene(hoverEvent->posF());
+            goEvent.sceneX = scenePos.x();
+            goEvent.sceneY = scenePos.y();
+            goEvent.modifiers = hoverEvent->modifiers();
+            break;
+        }
+    case EventWheel:
+        {
+            QWheelEvent *wheelEvent = static_cast<QWheelEvent *>(event);
+            goEvent.x = wheelEvent->posF().x();
+            goEvent.y = wheelEvent->posF().y();
+            QPointF scenePos = item->mapToScene(wheelEvent->posF());
+            goEvent.sceneX = scenePos.x();
+            goEvent.sceneY = scenePos.y();
+            goEvent.buttons = wheelEvent->buttons();
+            goEvent.modifiers = wheelEvent->modifiers();
+            goEvent.angleDeltaX = wheelEvent->angleDelta().x();
+            goEvent.angleDeltaY = wheelEvent->angleDelta().y();
+            break;
+        }
+    case EventTouch:
+        {
+            QTouchEvent *touchEvent = static_cast<QTouchEvent *>(event);
+            goEvent.modifiers = touchEvent->modifiers();
+            const QList<QTouchEvent::TouchPoint> &points = touchEvent->touchPoints();
+            for (int i = 0; i < points.size(); i++) {
+                const QTouchEvent::TouchPoint &point = points.at(i);
+                GoTouchPoint goPoint;
+                goPoint.id = point.id();
+                goPoint.state = point.state();
+                goPoint.x = point.pos().x();
+                goPoint.y = point.pos().y();
+                goPoint.pressure = point.pressure();
+                touchPoints.append(goPoint);
+            }
+            goEvent.touchPoints = touchPoints.data();
+            goEvent.touchPointsLen = touchPoints.size();
+            break;
+        }
+    case EventKeyPress:
+    case EventKeyRelease:
+        {
+            QKeyEvent *keyEvent = static_cast<QKeyEvent *>(event);
+            text = keyEvent->text().toUtf8();
+            goEvent.key = keyEvent->key();
+            goEvent.text = text.data();
+            goEvent.textLen = text.size();
+            goEvent.modifiers = keyEvent->modifiers();
+            goEvent.autoRepeat = keyEvent->isAutoRepeat();
+            break;
+        }
+    case EventFocusIn:
+    case EventFocusOut:
+        goEvent.reason = static_cast<QFocusEvent *>(event)->reason();
+        break;
+    }
+
+    hookGoValueInputEvent(qmlEngine(item), addr, reflectIndex, &goEvent);
+
+    if (kind == EventFocusIn || kind == EventFocusOut) {
+        return false;
+    }
+    event->setAccepted(goEvent.accepted);
+    if (!goEvent.accepted && (kind == EventKeyPress || kind == EventKeyRelease)) {
+        return false;
+    }
+    return true;
+}
+
 GoPaintedValue::GoPaintedValue(GoAddr *addr, GoTypeInfo *typeInfo, QObject *parent)
     : addr(addr), typeInfo(typeInfo)
 {
@@ -146,6 +297,7 @@ GoPaintedValue::GoPaintedValue(GoAddr *addr, GoTypeInfo *typeInfo, QObject *pare
 
     QQuickItem::setFlag(QQuickItem::ItemHasContents, true);
     QQuickPaintedItem::setRenderTarget(QQuickPaintedItem::FramebufferObject);
+    setupInputEvents(this, typeInfo);
 }
 
 GoPaintedValue::~GoPaintedValue()
@@ -160,11 +312,60 @@ void GoPaintedValue::activate(int propIndex)
 
 void GoPaintedValue::paint(QPainter *painter)
 {
+    // Painting starts in native mode for OpenGL calls. The Go side
+    // leaves it for 2D drawing, and returns to it before it's done.
     painter->beginNativePainting();
-    hookGoValuePaint(qmlEngine(this), addr, typeInfo->paint->reflectIndex);
+    hookGoValuePaint(qmlEngine(this), addr, typeInfo->paint->reflectIndex, painter);
     painter->endNativePainting();
 }
 
+bool GoPaintedValue::event(QEvent *event)
+{
+    if (dispatchInputEvent(this, addr, typeInfo, event)) {
+        return true;
+    }
+    return QQuickPaintedItem::event(event);
+}
+
+GoQuickItem::GoQuickItem(GoAddr *addr, GoTypeInfo *typeInfo, QObject *parent)
+    : addr(addr), typeInfo(typeInfo)
+{
+    valueMeta = new GoValueMetaObject(this, addr, typeInfo);
+    setParent(parent);
+
+    QQuickItem::setFlag(QQuickItem::ItemHasContents, true);
+    setupInputEvents(this, typeInfo);
+}
+
+GoQuickItem::~GoQuickItem()
+{
+    hookGoValueDestroyed(qmlEngine(this), addr);
+}
+
+void GoQuickItem::activate(int propIndex)
+{
+    valueMeta->activatePropIndex(propIndex);
+}
+
+QSGNode *GoQuickItem::updatePaintNode(QSGNode *oldNode, UpdatePaintNodeData *data)
+{
+    Q_UNUSED(data);
+    QSGNode *root = oldNode;
+    if (!root) {
+        root = new QSGNode();
+    }
+    hookGoValueUpdatePaintNode(qmlEngine(this), addr, typeInfo->updatePaintNode->reflectIndex, this, root);
+    return root;
+}
+
+bool GoQuickItem::event(QEvent *event)
+{
+    if (dispatchInputEvent(this, addr, typeInfo, event)) {
+        return true;
+    }
+    return QQuickItem::event(event);
+}
+
 QMetaObject *metaObjectFor(GoTypeInfo *typeInfo)
 {
     if (typeInfo->metaObject) {
@@ -174,6 +375,8 @@ QMetaObject *metaObjectFor(GoTypeInfo *typeInfo)
     QMetaObjectBuilder mob;
     if (typeInfo->paint) {
         mob.setSuperClass(&QQuickPaintedItem::staticMetaObject);
+    } else if (typeInfo->updatePaintNode) {
+        mob.setSuperClass(&QQuickItem::staticMetaObject);
     } else {
         mob.setSuperClass(&QObject::staticMetaObject);
     }
@@ -185,13 +388,21 @@ QMetaObject *metaObjectFor(GoTypeInfo *typeInfo)
     memberInfo = typeInfo->fields;
     int relativePropIndex = mob.propertyCount();
     for (int i = 0; i < typeInfo->fieldsLen; i++) {
-        mob.addSignal("__" + QByteArray::number(relativePropIndex) + "()");
+        // The notify signal index must match the property index for activatePropIndex.
+        QMetaMethodBuilder signalb;
+        if (memberInfo->notifySignal) {
+            signalb = mob.addSignal(QByteArray(memberInfo->notifySignal) + "()");
+        } else {
+            signalb = mob.addSignal("__" + QByteArray::number(relativePropIndex) + "()");
+        }
+        signalb.setRevision(memberInfo->revision);
         const char *typeName = "QVariant";
         if (memberInfo->memberType == DTListProperty) {
             typeName = "QQmlListProperty<QObject>";
         }
         QMetaPropertyBuilder propb = mob.addProperty(memberInfo->memberName, typeName, relativePropIndex);
-        propb.setWritable(true);
+        propb.setWritable(!memberInfo->readOnly);
+        propb.setRevision(memberInfo->revision);
         memberInfo->metaIndex = relativePropIndex;
         memberInfo++;
         relativePropIndex++;
@@ -200,18 +411,32 @@ QMetaObject *metaObjectFor(GoTypeInfo *typeInfo)
     memberInfo = typeInfo->methods;
     int relativeMethodIndex = mob.methodCount();
     for (int i = 0; i < typeInfo->methodsLen; i++) {
+        QMetaMethodBuilder methodb;
         if (*memberInfo->resultSignature) {
-            mob.addMethod(memberInfo->methodSignature, memberInfo->resultSignature);
+            methodb = mob.addMethod(memberInfo->methodSignature, memberInfo->resultSignature);
         } else {
-            mob.addMethod(memberInfo->methodSignature);
+            methodb = mob.addMethod(memberInfo->methodSignature);
         }
+        methodb.setRevision(memberInfo->revision);
         memberInfo->metaIndex = relativeMethodIndex;
         memberInfo++;
         relativeMethodIndex++;
     }
 
-    // TODO Support default properties.
-    //mob.addClassInfo("DefaultProperty", "objects");
+    GoEnumInfo *enumInfo = typeInfo->enums;
+    for (int i = 0; i < typeInfo->enumsLen; i++) {
+        QMetaEnumBuilder enumb = mob.addEnumerator(enumInfo->enumName);
+        const char *keyName = enumInfo->keyNames;
+        for (int j = 0; j < enumInfo->keysLen; j++) {
+            enumb.addKey(keyName, enumInfo->values[j]);
+            keyName += strlen(keyName) + 1;
+        }
+        enumInfo++;
+    }
+
+    if (typeInfo->defaultProperty) {
+        mob.addClassInfo("DefaultProperty", typeInfo->defaultProperty);
+    }
 
     QMetaObject *mo = mob.toMetaObject();
 
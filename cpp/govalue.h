@@ -7,6 +7,8 @@
 #include <private/qmetaobject_p.h>
 
 #include <QQuickPaintedItem>
+#include <QQuickItem>
+#include <QSGNode>
 #include <QPainter>
 
 #include "capi.h"
@@ -47,6 +49,30 @@ public:
 
     virtual void paint(QPainter *painter);
 
+protected:
+    virtual bool event(QEvent *event);
+
+private:
+    GoValueMetaObject *valueMeta;
+};
+
+class GoQuickItem : public QQuickItem
+{
+    Q_OBJECT
+
+public:
+    GoAddr *addr;
+    GoTypeInfo *typeInfo;
+
+    GoQuickItem(GoAddr *addr, GoTypeInfo *typeInfo, QObject *parent);
+    virtual ~GoQuickItem();
+
+    void activate(int propIndex);
+
+protected:
+    virtual QSGNode *updatePaintNode(QSGNode *oldNode, UpdatePaintNodeData *data);
+    virtual bool event(QEvent *event);
+
 private:
     GoValueMetaObject *valueMeta;
 };
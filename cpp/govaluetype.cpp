@@ -1,14 +1,106 @@
 #include "govaluetype.h"
 
+void goTypeClassBegin(QObject *value, GoAddr *addr, GoTypeInfo *typeInfo)
+{
+    int reflectIndex = typeInfo->hookMethods[HookClassBegin];
+    if (reflectIndex >= 0) {
+        hookGoValueLifecycle(qmlEngine(value), addr, reflectIndex);
+    }
+}
+
+void goTypeComponentComplete(QObject *value, GoAddr *addr, GoTypeInfo *typeInfo, GoTypeSpec_ *spec)
+{
+    if (typeInfo->specComplete) {
+        hookGoValueSpecComplete(qmlEngine(value), addr, spec);
+    }
+    int reflectIndex = typeInfo->hookMethods[HookComponentComplete];
+    if (reflectIndex >= 0) {
+        hookGoValueLifecycle(qmlEngine(value), addr, reflectIndex);
+    }
+}
+
+void goTypeGeometryChanged(QQuickItem *item, GoAddr *addr, GoTypeInfo *typeInfo, const QRectF &newGeometry, const QRectF &oldGeometry)
+{
+    int reflectIndex = typeInfo->hookMethods[HookGeometryChanged];
+    if (reflectIndex < 0) {
+        return;
+    }
+    double newRect[4] = { newGeometry.x(), newGeometry.y(), newGeometry.width(), newGeometry.height() };
+    double oldRect[4] = { oldGeometry.x(), oldGeometry.y(), oldGeometry.width(), oldGeometry.height() };
+    hookGoValueGeometryChanged(qmlEngine(item), addr, reflectIndex, newRect, oldRect);
+}
+
+void goTypeItemChange(QQuickItem *item, GoAddr *addr, GoTypeInfo *typeInfo, ChildTypes &childTypes, QQuickItem::ItemChange change, const QQuickItem::ItemChangeData &value)
+{
+    int reflectIndex = typeInfo->hookMethods[HookItemChanged];
+    if (reflectIndex < 0) {
+        return;
+    }
+    // Objects are always handed over as such, even if they hold Go values.
+    QObject *object = 0;
+    QVariant var;
+    switch (change) {
+    case QQuickItem::ItemChildAddedChange:
+        childTypes.insert(value.item, &typeid(*value.item));
+        object = value.item;
+        break;
+    case QQuickItem::ItemChildRemovedChange:
+        {
+            // A child being destroyed is removed from within ~QQuickItem,
+            // after its more specific parts and any Go value it holds are
+            // gone, which is noticed by its dynamic type having changed.
+            // That can't be noticed for plain QQuickItem children, as
+            // documented next to ItemChildRemoved.
+            const std::type_info *childType = childTypes.take(value.item);
+            if (childType && *childType == typeid(*value.item)) {
+                object = value.item;
+            }
+            break;
+        }
+    case QQuickItem::ItemParentHasChanged:
+        object = value.item;
+        break;
+    case QQuickItem::ItemSceneChange:
+        object = value.window;
+        break;
+    case QQuickItem::ItemVisibleHasChanged:
+    case QQuickItem::ItemActiveFocusHasChanged:
+        var = QVariant(value.boolValue);
+        break;
+    case QQuickItem::ItemOpacityHasChanged:
+    case QQuickItem::ItemRotationHasChanged:
+        var = QVariant(value.realValue);
+        break;
+    default:
+        return;
+    }
+    DataValue dvalue;
+    if (object) {
+        dvalue.dataType = DTObject;
+        *(void **)(dvalue.data) = object;
+    } else {
+        packDataValue(&var, &dvalue);
+    }
+    hookGoValueItemChanged(qmlEngine(item), addr, reflectIndex, change, &dvalue);
+}
+
 #define DEFINE_GOVALUETYPE(N) \
     template<> QMetaObject GoValueType<N>::staticMetaObject = QMetaObject(); \
     template<> GoTypeInfo *GoValueType<N>::typeInfo = 0; \
-    template<> GoTypeSpec_ *GoValueType<N>::typeSpec = 0;
+    template<> GoTypeSpec_ *GoValueType<N>::typeSpec = 0; \
+    template<> GoTypeInfo *GoValueType<N>::attachedInfo = 0;
 
 #define DEFINE_GOPAINTEDVALUETYPE(N) \
     template<> QMetaObject GoPaintedValueType<N>::staticMetaObject = QMetaObject(); \
     template<> GoTypeInfo *GoPaintedValueType<N>::typeInfo = 0; \
-    template<> GoTypeSpec_ *GoPaintedValueType<N>::typeSpec = 0;
+    template<> GoTypeSpec_ *GoPaintedValueType<N>::typeSpec = 0; \
+    template<> GoTypeInfo *GoPaintedValueType<N>::attachedInfo = 0;
+
+#define DEFINE_GOQUICKITEMTYPE(N) \
+    template<> QMetaObject GoQuickItemType<N>::staticMetaObject = QMetaObject(); \
+    template<> GoTypeInfo *GoQuickItemType<N>::typeInfo = 0; \
+    template<> GoTypeSpec_ *GoQuickItemType<N>::typeSpec = 0; \
+    template<> GoTypeInfo *GoQuickItemType<N>::attachedInfo = 0;
 
 DEFINE_GOVALUETYPE(1)
 DEFINE_GOVALUETYPE(2)
@@ -72,37 +164,168 @@ DEFINE_GOPAINTEDVALUETYPE(28)
 DEFINE_GOPAINTEDVALUETYPE(29)
 DEFINE_GOPAINTEDVALUETYPE(30)
 
+DEFINE_GOQUICKITEMTYPE(1)
+DEFINE_GOQUICKITEMTYPE(2)
+DEFINE_GOQUICKITEMTYPE(3)
+DEFINE_GOQUICKITEMTYPE(4)
+DEFINE_GOQUICKITEMTYPE(5)
+DEFINE_GOQUICKITEMTYPE(6)
+DEFINE_GOQUICKITEMTYPE(7)
+DEFINE_GOQUICKITEMTYPE(8)
+DEFINE_GOQUICKITEMTYPE(9)
+DEFINE_GOQUICKITEMTYPE(10)
+DEFINE_GOQUICKITEMTYPE(11)
+DEFINE_GOQUICKITEMTYPE(12)
+DEFINE_GOQUICKITEMTYPE(13)
+DEFINE_GOQUICKITEMTYPE(14)
+DEFINE_GOQUICKITEMTYPE(15)
+DEFINE_GOQUICKITEMTYPE(16)
+DEFINE_GOQUICKITEMTYPE(17)
+DEFINE_GOQUICKITEMTYPE(18)
+DEFINE_GOQUICKITEMTYPE(19)
+DEFINE_GOQUICKITEMTYPE(20)
+DEFINE_GOQUICKITEMTYPE(21)
+DEFINE_GOQUICKITEMTYPE(22)
+DEFINE_GOQUICKITEMTYPE(23)
+DEFINE_GOQUICKITEMTYPE(24)
+DEFINE_GOQUICKITEMTYPE(25)
+DEFINE_GOQUICKITEMTYPE(26)
+DEFINE_GOQUICKITEMTYPE(27)
+DEFINE_GOQUICKITEMTYPE(28)
+DEFINE_GOQUICKITEMTYPE(29)
+DEFINE_GOQUICKITEMTYPE(30)
+
 static int goValueTypeN = 0;
 static int goPaintedValueTypeN = 0;
+static int goQuickItemTypeN = 0;
+
+// registerTypeT registers T as qmlRegisterType and qmlRegisterUncreatableType
+// would, but with the metaobject revision defined at runtime rather than as
+// a template argument. T cannot be instantiated by QML if uncreatable is set.
+template<typename T>
+int registerTypeT(char *location, int major, int minor, char *name, int revision, char *uncreatable)
+{
+    QML_GETTYPENAMES
 
-template<int N>
-int registerSingletonN(char *location, int major, int minor, char *name, GoTypeInfo *info, GoTypeSpec_ *spec) {
-    GoValueType<N>::init(info, spec);
-    return qmlRegisterSingletonType< GoValueType<N> >(location, major, minor, name, [](QQmlEngine *qmlEngine, QJSEngine *jsEngine) -> QObject* {
-        QObject *singleton = new GoValueType<N>();
-        QQmlEngine::setContextForObject(singleton, qmlEngine->rootContext());
-        return singleton;
-    });
+    QQmlPrivate::RegisterType type = {};
+    type.version = 0;
+    type.typeId = qRegisterNormalizedMetaType<T *>(pointerName.constData());
+    type.listId = qRegisterNormalizedMetaType<QQmlListProperty<T> >(listName.constData());
+    if (uncreatable) {
+        type.noCreationReason = QString::fromUtf8(uncreatable);
+    } else {
+        type.objectSize = sizeof(T);
+        type.create = QQmlPrivate::createInto<T>;
+    }
+    type.uri = location;
+    type.versionMajor = major;
+    type.versionMinor = minor;
+    type.elementName = name;
+    type.metaObject = &T::staticMetaObject;
+    type.parserStatusCast = QQmlPrivate::StaticCastSelector<T, QQmlParserStatus>::cast();
+    type.valueSourceCast = QQmlPrivate::StaticCastSelector<T, QQmlPropertyValueSource>::cast();
+    type.valueInterceptorCast = QQmlPrivate::StaticCastSelector<T, QQmlPropertyValueInterceptor>::cast();
+    if (T::attachedInfo) {
+        type.attachedPropertiesFunction = T::qmlAttachedProperties;
+        type.attachedPropertiesMetaObject = metaObjectFor(T::attachedInfo);
+    }
+    type.revision = revision;
+    return QQmlPrivate::qmlregister(QQmlPrivate::TypeRegistration, &type);
+}
+
+// attacheeEngine returns the engine of attachee, or of its closest parent
+// that has one, as objects created from C++ or that had their context
+// cleared are not associated with an engine themselves.
+QQmlEngine *attacheeEngine(QObject *attachee)
+{
+    for (QObject *object = attachee; object; object = object->parent()) {
+        if (QQmlEngine *engine = qmlEngine(object)) {
+            return engine;
+        }
+    }
+    return 0;
 }
 
-template<int N>
-int registerPaintedSingletonN(char *location, int major, int minor, char *name, GoTypeInfo *info, GoTypeSpec_ *spec) {
-    GoPaintedValueType<N>::init(info, spec);
-    return qmlRegisterSingletonType< GoPaintedValueType<N> >(location, major, minor, name, [](QQmlEngine *qmlEngine, QJSEngine *jsEngine) -> QObject* {
-        QObject *singleton = new GoPaintedValueType<N>();
-        QQmlEngine::setContextForObject(singleton, qmlEngine->rootContext());
-        return singleton;
-    });
+template<typename T>
+QObject *singletonProvider(QQmlEngine *qmlEngine, QJSEngine *jsEngine)
+{
+    QObject *singleton = new T(qmlEngine);
+    QQmlEngine::setContextForObject(singleton, qmlEngine->rootContext());
+    return singleton;
+}
+
+// registerSingletonT registers T as qmlRegisterSingletonType would, but with
+// the metaobject revision defined at runtime rather than as a template argument.
+template<typename T>
+int registerSingletonT(char *location, int major, int minor, char *name, int revision)
+{
+    if (revision == 0) {
+        return qmlRegisterSingletonType<T>(location, major, minor, name, singletonProvider<T>);
+    }
+#if QT_VERSION < QT_VERSION_CHECK(5, 6, 0)
+    panicf("singleton type revisions require Qt 5.6 or later");
+    return -1;
+#else
+    QML_GETTYPENAMES
+
+    QQmlPrivate::RegisterSingletonType api = {};
+    api.version = 2;
+    api.uri = location;
+    api.versionMajor = major;
+    api.versionMinor = minor;
+    api.typeName = name;
+    api.qobjectApi = singletonProvider<T>;
+    api.instanceMetaObject = &T::staticMetaObject;
+    api.typeId = qRegisterNormalizedMetaType<T *>(pointerName.constData());
+    api.revision = revision;
+    return QQmlPrivate::qmlregister(QQmlPrivate::SingletonRegistration, &api);
+#endif
 }
 
 #define GOVALUETYPE_CASE_SINGLETON(N) \
-        case N: return registerSingletonN<N>(location, major, minor, name, info, spec);
+        case N: GoValueType<N>::init(info, spec); return registerSingletonT< GoValueType<N> >(location, major, minor, name, revision);
 #define GOPAINTEDVALUETYPE_CASE_SINGLETON(N) \
-        case N: return registerPaintedSingletonN<N>(location, major, minor, name, info, spec);
+        case N: GoPaintedValueType<N>::init(info, spec); return registerSingletonT< GoPaintedValueType<N> >(location, major, minor, name, revision);
 
-int registerSingleton(char *location, int major, int minor, char *name, GoTypeInfo *info, GoTypeSpec_ *spec)
+#define GOQUICKITEMTYPE_CASE_SINGLETON(N) \
+        case N: GoQuickItemType<N>::init(info, spec); return registerSingletonT< GoQuickItemType<N> >(location, major, minor, name, revision);
+
+int registerSingleton(char *location, int major, int minor, char *name, GoTypeInfo *info, GoTypeSpec_ *spec, int revision)
 {
-    if (!info->paint) {
+    if (info->updatePaintNode) {
+        switch (++goQuickItemTypeN) {
+        GOQUICKITEMTYPE_CASE_SINGLETON(1)
+        GOQUICKITEMTYPE_CASE_SINGLETON(2)
+        GOQUICKITEMTYPE_CASE_SINGLETON(3)
+        GOQUICKITEMTYPE_CASE_SINGLETON(4)
+        GOQUICKITEMTYPE_CASE_SINGLETON(5)
+        GOQUICKITEMTYPE_CASE_SINGLETON(6)
+        GOQUICKITEMTYPE_CASE_SINGLETON(7)
+        GOQUICKITEMTYPE_CASE_SINGLETON(8)
+        GOQUICKITEMTYPE_CASE_SINGLETON(9)
+        GOQUICKITEMTYPE_CASE_SINGLETON(10)
+        GOQUICKITEMTYPE_CASE_SINGLETON(11)
+        GOQUICKITEMTYPE_CASE_SINGLETON(12)
+        GOQUICKITEMTYPE_CASE_SINGLETON(13)
+        GOQUICKITEMTYPE_CASE_SINGLETON(14)
+        GOQUICKITEMTYPE_CASE_SINGLETON(15)
+        GOQUICKITEMTYPE_CASE_SINGLETON(16)
+        GOQUICKITEMTYPE_CASE_SINGLETON(17)
+        GOQUICKITEMTYPE_CASE_SINGLETON(18)
+        GOQUICKITEMTYPE_CASE_SINGLETON(19)
+        GOQUICKITEMTYPE_CASE_SINGLETON(20)
+        GOQUICKITEMTYPE_CASE_SINGLETON(21)
+        GOQUICKITEMTYPE_CASE_SINGLETON(22)
+        GOQUICKITEMTYPE_CASE_SINGLETON(23)
+        GOQUICKITEMTYPE_CASE_SINGLETON(24)
+        GOQUICKITEMTYPE_CASE_SINGLETON(25)
+        GOQUICKITEMTYPE_CASE_SINGLETON(26)
+        GOQUICKITEMTYPE_CASE_SINGLETON(27)
+        GOQUICKITEMTYPE_CASE_SINGLETON(28)
+        GOQUICKITEMTYPE_CASE_SINGLETON(29)
+        GOQUICKITEMTYPE_CASE_SINGLETON(30)
+        }
+    } else if (!info->paint) {
         switch (++goValueTypeN) {
         GOVALUETYPE_CASE_SINGLETON(1)
         GOVALUETYPE_CASE_SINGLETON(2)
@@ -174,13 +397,49 @@ int registerSingleton(char *location, int major, int minor, char *name, GoTypeIn
 }
 
 #define GOVALUETYPE_CASE(N) \
-    case N: GoValueType<N>::init(info, spec); return qmlRegisterType< GoValueType<N> >(location, major, minor, name);
+    case N: GoValueType<N>::init(info, spec, attachedInfo); return registerTypeT< GoValueType<N> >(location, major, minor, name, revision, uncreatable);
 #define GOPAINTEDVALUETYPE_CASE(N) \
-    case N: GoPaintedValueType<N>::init(info, spec); return qmlRegisterType< GoPaintedValueType<N> >(location, major, minor, name);
+    case N: GoPaintedValueType<N>::init(info, spec, attachedInfo); return registerTypeT< GoPaintedValueType<N> >(location, major, minor, name, revision, uncreatable);
 
-int registerType(char *location, int major, int minor, char *name, GoTypeInfo *info, GoTypeSpec_ *spec)
+#define GOQUICKITEMTYPE_CASE(N) \
+    case N: GoQuickItemType<N>::init(info, spec, attachedInfo); return registerTypeT< GoQuickItemType<N> >(location, major, minor, name, revision, uncreatable);
+
+int registerType(char *location, int major, int minor, char *name, GoTypeInfo *info, GoTypeSpec_ *spec, int revision, char *uncreatable, GoTypeInfo *attachedInfo)
 {
-    if (!info->paint) {
+    if (info->updatePaintNode) {
+        switch (++goQuickItemTypeN) {
+        GOQUICKITEMTYPE_CASE(1)
+        GOQUICKITEMTYPE_CASE(2)
+        GOQUICKITEMTYPE_CASE(3)
+        GOQUICKITEMTYPE_CASE(4)
+        GOQUICKITEMTYPE_CASE(5)
+        GOQUICKITEMTYPE_CASE(6)
+        GOQUICKITEMTYPE_CASE(7)
+        GOQUICKITEMTYPE_CASE(8)
+        GOQUICKITEMTYPE_CASE(9)
+        GOQUICKITEMTYPE_CASE(10)
+        GOQUICKITEMTYPE_CASE(11)
+        GOQUICKITEMTYPE_CASE(12)
+        GOQUICKITEMTYPE_CASE(13)
+        GOQUICKITEMTYPE_CASE(14)
+        GOQUICKITEMTYPE_CASE(15)
+        GOQUICKITEMTYPE_CASE(16)
+        GOQUICKITEMTYPE_CASE(17)
+        GOQUICKITEMTYPE_CASE(18)
+        GOQUICKITEMTYPE_CASE(19)
+        GOQUICKITEMTYPE_CASE(20)
+        GOQUICKITEMTYPE_CASE(21)
+        GOQUICKITEMTYPE_CASE(22)
+        GOQUICKITEMTYPE_CASE(23)
+        GOQUICKITEMTYPE_CASE(24)
+        GOQUICKITEMTYPE_CASE(25)
+        GOQUICKITEMTYPE_CASE(26)
+        GOQUICKITEMTYPE_CASE(27)
+        GOQUICKITEMTYPE_CASE(28)
+        GOQUICKITEMTYPE_CASE(29)
+        GOQUICKITEMTYPE_CASE(30)
+        }
+    } else if (!info->paint) {
         switch (++goValueTypeN) {
         GOVALUETYPE_CASE(1)
         GOVALUETYPE_CASE(2)
@@ -251,4 +510,28 @@ int registerType(char *location, int major, int minor, char *name, GoTypeInfo *i
     return 0;
 }
 
+int registerModule(char *location, int major, int minor)
+{
+#if QT_VERSION >= QT_VERSION_CHECK(5, 9, 0)
+    qmlRegisterModule(location, major, minor);
+    return 0;
+#else
+    return -1;
+#endif
+}
+
+error *engineSingleton(QQmlEngine_ *engine, int typeId, QObject_ **singleton)
+{
+#if QT_VERSION >= QT_VERSION_CHECK(5, 12, 0)
+    QObject *qsingleton = reinterpret_cast<QQmlEngine *>(engine)->singletonInstance<QObject *>(typeId);
+    if (!qsingleton) {
+        return errorf("engine failed to create the singleton instance");
+    }
+    *singleton = qsingleton;
+    return 0;
+#else
+    return errorf("Engine.Singleton requires Qt 5.12 or later; running with Qt %s", qVersion());
+#endif
+}
+
 // vim:sw=4:st=4:et:ft=cpp
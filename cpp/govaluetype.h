@@ -1,25 +1,72 @@
 #ifndef GOVALUETYPE_H
 #define GOVALUETYPE_H
 
+#include <QQmlEngine>
+#include <QHash>
+#include <typeinfo>
+
 #include "govalue.h"
 
+// ChildTypes holds the dynamic type of the children of an item,
+// so that children being destroyed may be noticed.
+typedef QHash<QQuickItem *, const std::type_info *> ChildTypes;
+
+void goTypeClassBegin(QObject *value, GoAddr *addr, GoTypeInfo *typeInfo);
+void goTypeComponentComplete(QObject *value, GoAddr *addr, GoTypeInfo *typeInfo, GoTypeSpec_ *spec);
+void goTypeGeometryChanged(QQuickItem *item, GoAddr *addr, GoTypeInfo *typeInfo, const QRectF &newGeometry, const QRectF &oldGeometry);
+void goTypeItemChange(QQuickItem *item, GoAddr *addr, GoTypeInfo *typeInfo, ChildTypes &childTypes, QQuickItem::ItemChange change, const QQuickItem::ItemChangeData &value);
+
+QQmlEngine *attacheeEngine(QObject *attachee);
+
+class GoAttachedValue : public GoValue
+{
+public:
+
+    GoAttachedValue(QQmlEngine *engine, QObject *attachee, GoTypeSpec_ *spec, GoTypeInfo *info)
+        : GoValue(hookGoValueAttachedNew(engine, this, attachee, spec), info, attachee) {};
+};
+
 template <int N>
-class GoValueType : public GoValue
+class GoValueType : public GoValue, public QQmlParserStatus
 {
 public:
 
     GoValueType()
         : GoValue(hookGoValueTypeNew(this, typeSpec), typeInfo, 0) {};
 
-    static void init(GoTypeInfo *info, GoTypeSpec_ *spec)
+    GoValueType(QQmlEngine *engine)
+        : GoValue(hookGoValueSingletonNew(engine, this, typeSpec), typeInfo, 0) {};
+
+    virtual void classBegin()
+    {
+        goTypeClassBegin(this, addr, typeInfo);
+    };
+
+    virtual void componentComplete()
+    {
+        goTypeComponentComplete(this, addr, typeInfo, typeSpec);
+    };
+
+    static void init(GoTypeInfo *info, GoTypeSpec_ *spec, GoTypeInfo *attached = 0)
     {
         typeInfo = info;
         typeSpec = spec;
+        attachedInfo = attached;
         static_cast<QMetaObject &>(staticMetaObject) = *metaObjectFor(typeInfo);
     };
 
+    static QObject *qmlAttachedProperties(QObject *attachee)
+    {
+        QQmlEngine *engine = attacheeEngine(attachee);
+        if (!engine) {
+            return 0;
+        }
+        return new GoAttachedValue(engine, attachee, typeSpec, attachedInfo);
+    };
+
     static GoTypeSpec_ *typeSpec;
     static GoTypeInfo *typeInfo;
+    static GoTypeInfo *attachedInfo;
     static QMetaObject staticMetaObject;
 };
 
@@ -31,16 +78,124 @@ public:
     GoPaintedValueType()
         : GoPaintedValue(hookGoValueTypeNew(this, typeSpec), typeInfo, 0) {};
 
-    static void init(GoTypeInfo *info, GoTypeSpec_ *spec)
+    GoPaintedValueType(QQmlEngine *engine)
+        : GoPaintedValue(hookGoValueSingletonNew(engine, this, typeSpec), typeInfo, 0) {};
+
+    virtual void classBegin()
+    {
+        GoPaintedValue::classBegin();
+        goTypeClassBegin(this, addr, typeInfo);
+    };
+
+    virtual void componentComplete()
+    {
+        GoPaintedValue::componentComplete();
+        goTypeComponentComplete(this, addr, typeInfo, typeSpec);
+    };
+
+    static void init(GoTypeInfo *info, GoTypeSpec_ *spec, GoTypeInfo *attached = 0)
     {
         typeInfo = info;
         typeSpec = spec;
+        attachedInfo = attached;
         static_cast<QMetaObject &>(staticMetaObject) = *metaObjectFor(typeInfo);
     };
 
+    static QObject *qmlAttachedProperties(QObject *attachee)
+    {
+        QQmlEngine *engine = attacheeEngine(attachee);
+        if (!engine) {
+            return 0;
+        }
+        return new GoAttachedValue(engine, attachee, typeSpec, attachedInfo);
+    };
+
     static GoTypeSpec_ *typeSpec;
     static GoTypeInfo *typeInfo;
+    static GoTypeInfo *attachedInfo;
     static QMetaObject staticMetaObject;
+
+protected:
+
+    virtual void geometryChanged(const QRectF &newGeometry, const QRectF &oldGeometry)
+    {
+        GoPaintedValue::geometryChanged(newGeometry, oldGeometry);
+        goTypeGeometryChanged(this, addr, typeInfo, newGeometry, oldGeometry);
+    };
+
+    virtual void itemChange(ItemChange change, const ItemChangeData &value)
+    {
+        GoPaintedValue::itemChange(change, value);
+        goTypeItemChange(this, addr, typeInfo, childTypes, change, value);
+    };
+
+private:
+
+    ChildTypes childTypes;
+};
+
+template <int N>
+class GoQuickItemType : public GoQuickItem
+{
+public:
+
+    GoQuickItemType()
+        : GoQuickItem(hookGoValueTypeNew(this, typeSpec), typeInfo, 0) {};
+
+    GoQuickItemType(QQmlEngine *engine)
+        : GoQuickItem(hookGoValueSingletonNew(engine, this, typeSpec), typeInfo, 0) {};
+
+    virtual void classBegin()
+    {
+        GoQuickItem::classBegin();
+        goTypeClassBegin(this, addr, typeInfo);
+    };
+
+    virtual void componentComplete()
+    {
+        GoQuickItem::componentComplete();
+        goTypeComponentComplete(this, addr, typeInfo, typeSpec);
+    };
+
+    static void init(GoTypeInfo *info, GoTypeSpec_ *spec, GoTypeInfo *attached = 0)
+    {
+        typeInfo = info;
+        typeSpec = spec;
+        attachedInfo = attached;
+        static_cast<QMetaObject &>(staticMetaObject) = *metaObjectFor(typeInfo);
+    };
+
+    static QObject *qmlAttachedProperties(QObject *attachee)
+    {
+        QQmlEngine *engine = attacheeEngine(attachee);
+        if (!engine) {
+            return 0;
+        }
+        return new GoAttachedValue(engine, attachee, typeSpec, attachedInfo);
+    };
+
+    static GoTypeSpec_ *typeSpec;
+    static GoTypeInfo *typeInfo;
+    static GoTypeInfo *attachedInfo;
+    static QMetaObject staticMetaObject;
+
+protected:
+
+    virtual void geometryChanged(const QRectF &newGeometry, const QRectF &oldGeometry)
+    {
+        GoQuickItem::geometryChanged(newGeometry, oldGeometry);
+        goTypeGeometryChanged(this, addr, typeInfo, newGeometry, oldGeometry);
+    };
+
+    virtual void itemChange(ItemChange change, const ItemChangeData &value)
+    {
+        GoQuickItem::itemChange(change, value);
+        goTypeItemChange(this, addr, typeInfo, childTypes, change, value);
+    };
+
+private:
+
+    ChildTypes childTypes;
 };
 
 #endif // GOVALUETYPE_H
@@ -26,6 +26,11 @@ class IdleTimer : public QObject
         timer.start(0, this);
     }
 
+    void stop()
+    {
+        timer.stop();
+    }
+
     protected:
 
     void timerEvent(QTimerEvent *event)
@@ -55,4 +60,9 @@ void idleTimerStart()
     QMetaObject::invokeMethod(IdleTimer::singleton(), "start", Qt::QueuedConnection);
 }
 
+void idleTimerStop()
+{
+    IdleTimer::singleton()->stop();
+}
+
 // vim:ts=4:sw=4:et:ft=cpp
@@ -152,4 +152,71 @@ int GoPaintedValue::qt_metacall(QMetaObject::Call _c, int _id, void **_a)
         return _id;
     return _id;
 }
+struct qt_meta_stringdata_GoQuickItem_t {
+    QByteArrayData data[1];
+    char stringdata[12];
+};
+#define QT_MOC_LITERAL(idx, ofs, len) \
+    Q_STATIC_BYTE_ARRAY_DATA_HEADER_INITIALIZER_WITH_OFFSET(len, \
+    offsetof(qt_meta_stringdata_GoQuickItem_t, stringdata) + ofs \
+        - idx * sizeof(QByteArrayData) \
+    )
+static const qt_meta_stringdata_GoQuickItem_t qt_meta_stringdata_GoQuickItem = {
+    {
+QT_MOC_LITERAL(0, 0, 11)
+    },
+    "GoQuickItem\0"
+};
+#undef QT_MOC_LITERAL
+
+static const uint qt_meta_data_GoQuickItem[] = {
+
+ // content:
+       7,       // revision
+       0,       // classname
+       0,    0, // classinfo
+       0,    0, // methods
+       0,    0, // properties
+       0,    0, // enums/sets
+       0,    0, // constructors
+       0,       // flags
+       0,       // signalCount
+
+       0        // eod
+};
+
+void GoQuickItem::qt_static_metacall(QObject *_o, QMetaObject::Call _c, int _id, void **_a)
+{
+    Q_UNUSED(_o);
+    Q_UNUSED(_id);
+    Q_UNUSED(_c);
+    Q_UNUSED(_a);
+}
+
+const QMetaObject GoQuickItem::staticMetaObject = {
+    { &QQuickItem::staticMetaObject, qt_meta_stringdata_GoQuickItem.data,
+      qt_meta_data_GoQuickItem,  qt_static_metacall, 0, 0}
+};
+
+
+const QMetaObject *GoQuickItem::metaObject() const
+{
+    return QObject::d_ptr->metaObject ? QObject::d_ptr->dynamicMetaObject() : &staticMetaObject;
+}
+
+void *GoQuickItem::qt_metacast(const char *_clname)
+{
+    if (!_clname) return 0;
+    if (!strcmp(_clname, qt_meta_stringdata_GoQuickItem.stringdata))
+        return static_cast<void*>(const_cast< GoQuickItem*>(this));
+    return QQuickItem::qt_metacast(_clname);
+}
+
+int GoQuickItem::qt_metacall(QMetaObject::Call _c, int _id, void **_a)
+{
+    _id = QQuickItem::qt_metacall(_c, _id, _a);
+    if (_id < 0)
+        return _id;
+    return _id;
+}
 QT_END_MOC_NAMESPACE
@@ -0,0 +1,230 @@
+#include <QPainter>
+#include <QPainterPath>
+#include <QFontMetricsF>
+#include <QLinearGradient>
+#include <QRadialGradient>
+
+#include "capi.h"
+
+// These tables map the Go pen constants onto the Qt ones, so that the
+// zero value of qml.Pen draws a solid line.
+static const Qt::PenStyle penStyles[] = {
+    Qt::SolidLine, Qt::NoPen, Qt::DashLine, Qt::DotLine, Qt::DashDotLine, Qt::DashDotDotLine
+};
+static const Qt::PenCapStyle penCaps[] = { Qt::FlatCap, Qt::SquareCap, Qt::RoundCap };
+static const Qt::PenJoinStyle penJoins[] = { Qt::MiterJoin, Qt::BevelJoin, Qt::RoundJoin };
+
+static QPainterPath newPainterPath(int *ops, int opsLen, double *coords)
+{
+    QPainterPath path;
+    double *c = coords;
+    for (int i = 0; i < opsLen; i++) {
+        switch (ops[i]) {
+        case PathMoveTo:
+            path.moveTo(c[0], c[1]);
+            c += 2;
+            break;
+        case PathLineTo:
+            path.lineTo(c[0], c[1]);
+            c += 2;
+            break;
+        case PathQuadTo:
+            path.quadTo(c[0], c[1], c[2], c[3]);
+            c += 4;
+            break;
+        case PathCubicTo:
+            path.cubicTo(c[0], c[1], c[2], c[3], c[4], c[5]);
+            c += 6;
+            break;
+        case PathArcTo:
+            path.arcTo(c[0], c[1], c[2], c[3], c[4], c[5]);
+            c += 6;
+            break;
+        case PathClose:
+            path.closeSubpath();
+            break;
+        case PathAddRect:
+            path.addRect(c[0], c[1], c[2], c[3]);
+            c += 4;
+            break;
+        case PathAddEllipse:
+            path.addEllipse(c[0], c[1], c[2], c[3]);
+            c += 4;
+            break;
+        case PathAddRoundedRect:
+            path.addRoundedRect(c[0], c[1], c[2], c[3], c[4], c[5]);
+            c += 6;
+            break;
+        default:
+            panicf("unknown path operation: %d", ops[i]);
+        }
+    }
+    return path;
+}
+
+void painterBeginNativePainting(QPainter_ *painter)
+{
+    reinterpret_cast<QPainter *>(painter)->beginNativePainting();
+}
+
+void painterEndNativePainting(QPainter_ *painter)
+{
+    reinterpret_cast<QPainter *>(painter)->endNativePainting();
+}
+
+void painterSave(QPainter_ *painter)
+{
+    reinterpret_cast<QPainter *>(painter)->save();
+}
+
+void painterRestore(QPainter_ *painter)
+{
+    reinterpret_cast<QPainter *>(painter)->restore();
+}
+
+void painterSetRenderHint(QPainter_ *painter, int hint, int on)
+{
+    reinterpret_cast<QPainter *>(painter)->setRenderHint(QPainter::RenderHint(hint), on);
+}
+
+void painterSetOpacity(QPainter_ *painter, double opacity)
+{
+    reinterpret_cast<QPainter *>(painter)->setOpacity(opacity);
+}
+
+void painterSetPen(QPainter_ *painter, unsigned int color, double width, int style, int cap, int join)
+{
+    QPen pen(QColor::fromRgba(color));
+    pen.setWidthF(width);
+    pen.setStyle(penStyles[style]);
+    pen.setCapStyle(penCaps[cap]);
+    pen.setJoinStyle(penJoins[join]);
+    reinterpret_cast<QPainter *>(painter)->setPen(pen);
+}
+
+void painterSetBrush(QPainter_ *painter, int kind, unsigned int color, double *coords, double *stopPositions, unsigned int *stopColors, int stopsLen)
+{
+    QPainter *qpainter = reinterpret_cast<QPainter *>(painter);
+    QGradient *gradient = 0;
+    switch (kind) {
+    case BrushNone:
+        qpainter->setBrush(Qt::NoBrush);
+        return;
+    case BrushColor:
+        qpainter->setBrush(QColor::fromRgba(color));
+        return;
+    case BrushLinearGradient:
+        gradient = new QLinearGradient(coords[0], coords[1], coords[2], coords[3]);
+        break;
+    case BrushRadialGradient:
+        gradient = new QRadialGradient(coords[0], coords[1], coords[2], coords[3], coords[4]);
+        break;
+    default:
+        panicf("unknown brush kind: %d", kind);
+    }
+    for (int i = 0; i < stopsLen; i++) {
+        gradient->setColorAt(stopPositions[i], QColor::fromRgba(stopColors[i]));
+    }
+    qpainter->setBrush(*gradient);
+    delete gradient;
+}
+
+void painterSetFont(QPainter_ *painter, char *family, int familyLen, double pointSize, int pixelSize, int bold, int italic)
+{
+    QFont font(QString::fromUtf8(family, familyLen));
+    if (pixelSize > 0) {
+        font.setPixelSize(pixelSize);
+    } else if (pointSize > 0) {
+        font.setPointSizeF(pointSize);
+    }
+    font.setBold(bold);
+    font.setItalic(italic);
+    reinterpret_cast<QPainter *>(painter)->setFont(font);
+}
+
+void painterTranslate(QPainter_ *painter, double dx, double dy)
+{
+    reinterpret_cast<QPainter *>(painter)->translate(dx, dy);
+}
+
+void painterRotate(QPainter_ *painter, double angle)
+{
+    reinterpret_cast<QPainter *>(painter)->rotate(angle);
+}
+
+void painterScale(QPainter_ *painter, double sx, double sy)
+{
+    reinterpret_cast<QPainter *>(painter)->scale(sx, sy);
+}
+
+void painterResetTransform(QPainter_ *painter)
+{
+    reinterpret_cast<QPainter *>(painter)->resetTransform();
+}
+
+void painterSetClipRect(QPainter_ *painter, double x, double y, double width, double height)
+{
+    reinterpret_cast<QPainter *>(painter)->setClipRect(QRectF(x, y, width, height));
+}
+
+void painterSetClipPath(QPainter_ *painter, int *ops, int opsLen, double *coords)
+{
+    reinterpret_cast<QPainter *>(painter)->setClipPath(newPainterPath(ops, opsLen, coords));
+}
+
+void painterSetClipping(QPainter_ *painter, int enabled)
+{
+    reinterpret_cast<QPainter *>(painter)->setClipping(enabled);
+}
+
+void painterDrawLine(QPainter_ *painter, double x1, double y1, double x2, double y2)
+{
+    reinterpret_cast<QPainter *>(painter)->drawLine(QLineF(x1, y1, x2, y2));
+}
+
+void painterDrawRect(QPainter_ *painter, double x, double y, double width, double height, double xRadius, double yRadius)
+{
+    QPainter *qpainter = reinterpret_cast<QPainter *>(painter);
+    if (xRadius > 0 || yRadius > 0) {
+        qpainter->drawRoundedRect(QRectF(x, y, width, height), xRadius, yRadius);
+    } else {
+        qpainter->drawRect(QRectF(x, y, width, height));
+    }
+}
+
+void painterDrawEllipse(QPainter_ *painter, double x, double y, double width, double height)
+{
+    reinterpret_cast<QPainter *>(painter)->drawEllipse(QRectF(x, y, width, height));
+}
+
+void painterDrawPath(QPainter_ *painter, int *ops, int opsLen, double *coords)
+{
+    reinterpret_cast<QPainter *>(painter)->drawPath(newPainterPath(ops, opsLen, coords));
+}
+
+void painterDrawText(QPainter_ *painter, double x, double y, char *text, int textLen)
+{
+    reinterpret_cast<QPainter *>(painter)->drawText(QPointF(x, y), QString::fromUtf8(text, textLen));
+}
+
+void painterDrawTextRect(QPainter_ *painter, double x, double y, double width, double height, int flags, char *text, int textLen)
+{
+    reinterpret_cast<QPainter *>(painter)->drawText(QRectF(x, y, width, height), flags, QString::fromUtf8(text, textLen));
+}
+
+void painterTextSize(QPainter_ *painter, char *text, int textLen, double *width, double *height)
+{
+    QFontMetricsF metrics(reinterpret_cast<QPainter *>(painter)->font());
+    QSizeF size = metrics.size(0, QString::fromUtf8(text, textLen));
+    *width = size.width();
+    *height = size.height();
+}
+
+void painterDrawImage(QPainter_ *painter, double x, double y, double width, double height, QImage_ *image)
+{
+    QImage *qimage = reinterpret_cast<QImage *>(image);
+    reinterpret_cast<QPainter *>(painter)->drawImage(QRectF(x, y, width, height), *qimage);
+    delete qimage;
+}
+
+// vim:ts=4:sw=4:et:ft=cpp
@@ -0,0 +1,106 @@
+#include <QQuickItem>
+#include <QQuickWindow>
+#include <QSGGeometryNode>
+#include <QSGFlatColorMaterial>
+#include <QSGSimpleTextureNode>
+
+#include "capi.h"
+
+// GoTextureNode is a texture node that owns its texture, which
+// QSGSimpleTextureNode only supports from Qt 5.4 onwards.
+class GoTextureNode : public QSGSimpleTextureNode
+{
+public:
+    ~GoTextureNode() { delete texture(); }
+};
+
+int sgNodeChildCount(QSGNode_ *node)
+{
+    return reinterpret_cast<QSGNode *>(node)->childCount();
+}
+
+void sgNodeRemoveAll(QSGNode_ *node)
+{
+    QSGNode *qnode = reinterpret_cast<QSGNode *>(node);
+    while (QSGNode *child = qnode->firstChild()) {
+        qnode->removeChildNode(child);
+        delete child;
+    }
+}
+
+static void setGeometryVertices(QSGGeometryNode *node, int mode, float *vertices, int verticesLen)
+{
+    int count = verticesLen / 2;
+    QSGGeometry *geometry = node->geometry();
+    geometry->allocate(count);
+    geometry->setDrawingMode(mode);
+    QSGGeometry::Point2D *points = geometry->vertexDataAsPoint2D();
+    for (int i = 0; i < count; i++) {
+        points[i].set(vertices[i*2], vertices[i*2+1]);
+    }
+    node->markDirty(QSGNode::DirtyGeometry);
+}
+
+QSGNode_ *sgNodeAddGeometry(QSGNode_ *parent, int mode, float *vertices, int verticesLen, unsigned int color)
+{
+    QSGGeometryNode *node = new QSGGeometryNode();
+    node->setGeometry(new QSGGeometry(QSGGeometry::defaultAttributes_Point2D(), 0));
+    node->setFlag(QSGNode::OwnsGeometry);
+    QSGFlatColorMaterial *material = new QSGFlatColorMaterial();
+    material->setColor(QColor::fromRgba(color));
+    node->setMaterial(material);
+    node->setFlag(QSGNode::OwnsMaterial);
+    setGeometryVertices(node, mode, vertices, verticesLen);
+    reinterpret_cast<QSGNode *>(parent)->appendChildNode(node);
+    return node;
+}
+
+void sgGeometrySetVertices(QSGNode_ *node, int mode, float *vertices, int verticesLen)
+{
+    setGeometryVertices(static_cast<QSGGeometryNode *>(reinterpret_cast<QSGNode *>(node)), mode, vertices, verticesLen);
+}
+
+void sgGeometrySetColor(QSGNode_ *node, unsigned int color)
+{
+    QSGGeometryNode *gnode = static_cast<QSGGeometryNode *>(reinterpret_cast<QSGNode *>(node));
+    static_cast<QSGFlatColorMaterial *>(gnode->material())->setColor(QColor::fromRgba(color));
+    gnode->markDirty(QSGNode::DirtyMaterial);
+}
+
+static QSGTexture *createTexture(QQuickItem_ *item, QImage_ *image)
+{
+    QImage *qimage = reinterpret_cast<QImage *>(image);
+    QQuickWindow *window = reinterpret_cast<QQuickItem *>(item)->window();
+    if (!window) {
+        delete qimage;
+        panicf("cannot create texture for an item that is not in a window");
+    }
+    QSGTexture *texture = window->createTextureFromImage(*qimage);
+    delete qimage;
+    return texture;
+}
+
+QSGNode_ *sgNodeAddTexture(QSGNode_ *parent, QQuickItem_ *item, QImage_ *image, double x, double y, double width, double height)
+{
+    QSGTexture *texture = createTexture(item, image);
+    GoTextureNode *node = new GoTextureNode();
+    node->setTexture(texture);
+    node->setRect(x, y, width, height);
+    reinterpret_cast<QSGNode *>(parent)->appendChildNode(node);
+    return static_cast<QSGNode *>(node);
+}
+
+void sgTextureSetImage(QSGNode_ *node, QQuickItem_ *item, QImage_ *image)
+{
+    GoTextureNode *tnode = static_cast<GoTextureNode *>(reinterpret_cast<QSGNode *>(node));
+    QSGTexture *old = tnode->texture();
+    tnode->setTexture(createTexture(item, image));
+    delete old;
+}
+
+void sgTextureSetRect(QSGNode_ *node, double x, double y, double width, double height)
+{
+    static_cast<GoTextureNode *>(reinterpret_cast<QSGNode *>(node))->setRect(x, y, width, height);
+}
+
+// vim:ts=4:sw=4:et:ft=cpp
@@ -1,5 +1,12 @@
 #include <string.h>
 
+#include <QCoreApplication>
+#include <QKeyEvent>
+#include <QMouseEvent>
+#include <QTouchEvent>
+#include <QWheelEvent>
+#include <QQuickItem>
+
 #include "cpptest.h"
 #include "testtype.h"
 
@@ -12,3 +19,63 @@ int plainTestTypeN(PlainTestType_ *plain)
 {
 	return static_cast<PlainTestType *>(plain)->n;
 }
+
+// The send functions deliver a synthetic event to object,
+// and return whether the event was accepted.
+
+int sendMouseEvent(QObject_ *object, int type, double x, double y, double sceneX, double sceneY, int button, int buttons, int modifiers)
+{
+	QPointF scenePos(sceneX, sceneY);
+	QMouseEvent event(QEvent::Type(type), QPointF(x, y), scenePos, scenePos, Qt::MouseButton(button), Qt::MouseButtons(buttons), Qt::KeyboardModifiers(modifiers));
+	QCoreApplication::sendEvent(static_cast<QObject *>(object), &event);
+	return event.isAccepted();
+}
+
+int sendHoverEvent(QObject_ *object, int type, double x, double y, int modifiers)
+{
+	QHoverEvent event(QEvent::Type(type), QPointF(x, y), QPointF(x, y), Qt::KeyboardModifiers(modifiers));
+	QCoreApplication::sendEvent(static_cast<QObject *>(object), &event);
+	return event.isAccepted();
+}
+
+int sendWheelEvent(QObject_ *object, double x, double y, int angleDeltaX, int angleDeltaY, int buttons, int modifiers)
+{
+	QWheelEvent event(QPointF(x, y), QPointF(x, y), QPoint(), QPoint(angleDeltaX, angleDeltaY), angleDeltaY, Qt::Vertical, Qt::MouseButtons(buttons), Qt::KeyboardModifiers(modifiers));
+	QCoreApplication::sendEvent(static_cast<QObject *>(object), &event);
+	return event.isAccepted();
+}
+
+int sendTouchEvent(QObject_ *object, int type, int id, int state, double x, double y, double pressure, int modifiers)
+{
+	static QTouchDevice *device = 0;
+	if (!device) {
+		device = new QTouchDevice();
+		device->setType(QTouchDevice::TouchScreen);
+	}
+	QTouchEvent::TouchPoint point(id);
+	point.setState(Qt::TouchPointState(state));
+	point.setPos(QPointF(x, y));
+	point.setPressure(pressure);
+	QList<QTouchEvent::TouchPoint> points;
+	points.append(point);
+	QTouchEvent event(QEvent::Type(type), device, Qt::KeyboardModifiers(modifiers), Qt::TouchPointStates(state), points);
+	QCoreApplication::sendEvent(static_cast<QObject *>(object), &event);
+	return event.isAccepted();
+}
+
+int sendKeyEvent(QObject_ *object, int type, int key, int modifiers, const char *text)
+{
+	QKeyEvent event(QEvent::Type(type), key, Qt::KeyboardModifiers(modifiers), QString::fromUtf8(text));
+	QCoreApplication::sendEvent(static_cast<QObject *>(object), &event);
+	return event.isAccepted();
+}
+
+int itemAcceptedMouseButtons(QObject_ *object)
+{
+	return static_cast<QQuickItem *>(static_cast<QObject *>(object))->acceptedMouseButtons();
+}
+
+int itemAcceptHoverEvents(QObject_ *object)
+{
+	return static_cast<QQuickItem *>(static_cast<QObject *>(object))->acceptHoverEvents();
+}
@@ -4,7 +4,7 @@ package cpptest
 // #cgo CXXFLAGS: -std=c++0x -Wall -fno-strict-aliasing -I..
 // #cgo LDFLAGS: -lstdc++
 //
-// #cgo pkg-config: Qt5Core
+// #cgo pkg-config: Qt5Core Qt5Gui Qt5Quick
 //
 // #include "cpptest.h"
 //
@@ -28,3 +28,89 @@ func NewTestType(engine *qml.Engine) qml.Object {
 func PlainTestTypeN(obj qml.Object) int {
 	return int(C.plainTestTypeN(unsafe.Pointer(obj.Property("plainAddr").(uintptr))))
 }
+
+// EventType identifies the type of a synthetic event. The values
+// match the respective QEvent types.
+type EventType int
+
+const (
+	MouseButtonPress    EventType = 2
+	MouseButtonRelease  EventType = 3
+	MouseButtonDblClick EventType = 4
+	MouseMove           EventType = 5
+	KeyPress            EventType = 6
+	KeyRelease          EventType = 7
+	HoverEnter          EventType = 127
+	HoverLeave          EventType = 128
+	HoverMove           EventType = 129
+	TouchBegin          EventType = 194
+	TouchUpdate         EventType = 195
+	TouchEnd            EventType = 196
+)
+
+func objectAddr(obj qml.Object) unsafe.Pointer {
+	return unsafe.Pointer(obj.Common().Addr())
+}
+
+// SendMouseEvent delivers a synthetic mouse event to obj and
+// reports whether it was accepted.
+func SendMouseEvent(obj qml.Object, typ EventType, x, y, sceneX, sceneY float64, button, buttons qml.MouseButton, modifiers qml.KeyboardModifier) (accepted bool) {
+	qml.RunMain(func() {
+		accepted = C.sendMouseEvent(objectAddr(obj), C.int(typ), C.double(x), C.double(y), C.double(sceneX), C.double(sceneY), C.int(button), C.int(buttons), C.int(modifiers)) != 0
+	})
+	return
+}
+
+// SendHoverEvent delivers a synthetic hover event to obj and
+// reports whether it was accepted.
+func SendHoverEvent(obj qml.Object, typ EventType, x, y float64, modifiers qml.KeyboardModifier) (accepted bool) {
+	qml.RunMain(func() {
+		accepted = C.sendHoverEvent(objectAddr(obj), C.int(typ), C.double(x), C.double(y), C.int(modifiers)) != 0
+	})
+	return
+}
+
+// SendWheelEvent delivers a synthetic wheel event to obj and
+// reports whether it was accepted.
+func SendWheelEvent(obj qml.Object, x, y float64, angleDeltaX, angleDeltaY int, buttons qml.MouseButton, modifiers qml.KeyboardModifier) (accepted bool) {
+	qml.RunMain(func() {
+		accepted = C.sendWheelEvent(objectAddr(obj), C.double(x), C.double(y), C.int(angleDeltaX), C.int(angleDeltaY), C.int(buttons), C.int(modifiers)) != 0
+	})
+	return
+}
+
+// SendTouchEvent delivers a synthetic touch event with a single
+// point to obj and reports whether it was accepted.
+func SendTouchEvent(obj qml.Object, typ EventType, point qml.TouchPoint, modifiers qml.KeyboardModifier) (accepted bool) {
+	qml.RunMain(func() {
+		accepted = C.sendTouchEvent(objectAddr(obj), C.int(typ), C.int(point.ID), C.int(point.State), C.double(point.X), C.double(point.Y), C.double(point.Pressure), C.int(modifiers)) != 0
+	})
+	return
+}
+
+// SendKeyEvent delivers a synthetic key event to obj and
+// reports whether it was accepted.
+func SendKeyEvent(obj qml.Object, typ EventType, key qml.Key, modifiers qml.KeyboardModifier, text string) (accepted bool) {
+	ctext := C.CString(text)
+	defer C.free(unsafe.Pointer(ctext))
+	qml.RunMain(func() {
+		accepted = C.sendKeyEvent(objectAddr(obj), C.int(typ), C.int(key), C.int(modifiers), ctext) != 0
+	})
+	return
+}
+
+// AcceptedMouseButtons returns the mouse buttons the item obj accepts.
+func AcceptedMouseButtons(obj qml.Object) (buttons qml.MouseButton) {
+	qml.RunMain(func() {
+		buttons = qml.MouseButton(C.itemAcceptedMouseButtons(objectAddr(obj)))
+	})
+	return
+}
+
+// AcceptHoverEvents returns whether the item obj accepts hover events.
+func AcceptHoverEvents(obj qml.Object) (accept bool) {
+	qml.RunMain(func() {
+		accept = C.itemAcceptHoverEvents(objectAddr(obj)) != 0
+	})
+	return
+}
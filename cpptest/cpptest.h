@@ -7,6 +7,7 @@
 
 typedef void TestType_;
 typedef void PlainTestType_;
+typedef void QObject_;
 
 #ifdef __cplusplus
 extern "C" {
@@ -16,6 +17,14 @@ TestType_ *newTestType();
 
 int plainTestTypeN(PlainTestType_ *plain);
 
+int sendMouseEvent(QObject_ *object, int type, double x, double y, double sceneX, double sceneY, int button, int buttons, int modifiers);
+int sendHoverEvent(QObject_ *object, int type, double x, double y, int modifiers);
+int sendWheelEvent(QObject_ *object, double x, double y, int angleDeltaX, int angleDeltaY, int buttons, int modifiers);
+int sendTouchEvent(QObject_ *object, int type, int id, int state, double x, double y, double pressure, int modifiers);
+int sendKeyEvent(QObject_ *object, int type, int key, int modifiers, const char *text);
+int itemAcceptedMouseButtons(QObject_ *object);
+int itemAcceptHoverEvents(QObject_ *object);
+
 #ifdef __cplusplus
 }
 #endif
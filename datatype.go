@@ -38,6 +38,11 @@ var (
 	typeList       = reflect.TypeOf(&List{})
 	typeMap        = reflect.TypeOf(&Map{})
 	typeGenericMap = reflect.TypeOf(map[string]interface{}(nil))
+	typeIfaceSlice = reflect.TypeOf([]interface{}(nil))
+	typeEmpty      = reflect.TypeOf(struct{}{})
+	typeObservable = reflect.TypeOf(Observable{})
+	typeEngine     = reflect.TypeOf(&Engine{})
+	typeSceneGraph = reflect.TypeOf(&SceneGraph{})
 )
 
 func init() {
@@ -109,6 +114,11 @@ func packDataValue(value interface{}, dvalue *C.DataValue, engine *Engine, owner
 		dvalue.dataType = C.DTColor
 		*(*uint32)(datap) = uint32(value.A)<<24 | uint32(value.R)<<16 | uint32(value.G)<<8 | uint32(value.B)
 	default:
+		if enumTypes[reflect.TypeOf(value)] {
+			dvalue.dataType = C.DTInt32
+			*(*int32)(datap) = int32(reflect.ValueOf(value).Convert(typeInt64).Int())
+			break
+		}
 		dvalue.dataType = C.DTObject
 		if obj, ok := value.(Object); ok {
 			*(*unsafe.Pointer)(datap) = obj.Common().addr
@@ -224,6 +234,8 @@ func dataTypeOf(typ reflect.Type) C.DataType {
 		return C.DTColor
 	case typeObjSlice:
 		return C.DTListProperty
+	case typeObservable:
+		return C.DTAny
 	}
 	return C.DTObject
 }
@@ -253,6 +265,25 @@ func appendLoweredName(buf []byte, name string) []byte {
 	return append(buf, string(unicode.ToLower(last))...)
 }
 
+// memberSpec holds details about a Go field, getter, or method
+// that is exposed to QML as a member of a type.
+type memberSpec struct {
+	name         string
+	dataType     C.DataType
+	reflectIndex int
+	getIndex     int
+	setIndex     int
+	addrOffset   uintptr
+	readOnly     bool
+	notify       string
+	revision     int
+
+	// For methods only.
+	method    reflect.Method
+	signature string
+	result    string
+}
+
 func typeInfo(v interface{}) *C.GoTypeInfo {
 	vt := reflect.TypeOf(v)
 	for vt.Kind() == reflect.Ptr {
@@ -268,6 +299,17 @@ func typeInfo(v interface{}) *C.GoTypeInfo {
 	typeInfo.typeName = C.CString(vt.Name())
 	typeInfo.metaObject = nilPtr
 	typeInfo.paint = (*C.GoMemberInfo)(nilPtr)
+	typeInfo.updatePaintNode = (*C.GoMemberInfo)(nilPtr)
+	typeInfo.enums = (*C.GoEnumInfo)(nilPtr)
+	typeInfo.enumsLen = 0
+	for i := range typeInfo.eventMethods {
+		typeInfo.eventMethods[i] = -1
+	}
+	for i := range typeInfo.hookMethods {
+		typeInfo.hookMethods[i] = -1
+	}
+	typeInfo.defaultProperty = nilCharPtr
+	typeInfo.specComplete = 0
 
 	var setters map[string]int
 	var getters map[string]int
@@ -281,26 +323,13 @@ func typeInfo(v interface{}) *C.GoTypeInfo {
 
 	numField := vt.NumField()
 	numMethod := vtptr.NumMethod()
-	privateFields := 0
-	privateMethods := 0
+	mtags := typeMethodTags(vt)
 
-	// struct { FooBar T; Baz T } => "fooBar\0baz\0"
-	namesLen := 0
-	for i := 0; i < numField; i++ {
-		field := vt.Field(i)
-		if field.PkgPath != "" {
-			privateFields++
-			continue
-		}
-		namesLen += len(field.Name) + 1
-	}
 	for i := 0; i < numMethod; i++ {
 		method := vtptr.Method(i)
 		if method.PkgPath != "" {
-			privateMethods++
-			continue
+			continue // not exported
 		}
-		namesLen += len(method.Name) + 1
 
 		// Track setters and getters.
 		if len(method.Name) > 3 && method.Name[:3] == "Set" {
@@ -317,149 +346,207 @@ func typeInfo(v interface{}) *C.GoTypeInfo {
 			getters[method.Name] = i
 		}
 	}
-	names := make([]byte, 0, namesLen)
+
+	// Fields and getters are exposed as properties, and other methods as methods.
+	var fields, methods []memberSpec
 	for i := 0; i < numField; i++ {
 		field := vt.Field(i)
 		if field.PkgPath != "" {
 			continue // not exported
 		}
-		names = appendLoweredName(names, field.Name)
-		names = append(names, 0)
+		tag := parseMemberTag(field.Tag.Get("qml"), vt, field.Name)
+		if tag.hidden {
+			continue
+		}
+		member := memberSpec{
+			name:         tag.name,
+			dataType:     dataTypeOf(field.Type),
+			reflectIndex: i,
+			getIndex:     -1,
+			setIndex:     -1,
+			addrOffset:   field.Offset,
+			readOnly:     tag.readOnly,
+			notify:       tag.notify,
+			revision:     tag.revision,
+		}
+		if member.name == "" {
+			member.name = string(appendLoweredName(nil, field.Name))
+		}
+		if methodIndex, ok := setters[field.Name]; ok && !tag.readOnly {
+			member.setIndex = methodIndex
+		}
+		fields = append(fields, member)
 	}
 	for i := 0; i < numMethod; i++ {
 		method := vtptr.Method(i)
 		if method.PkgPath != "" {
 			continue // not exported
 		}
-		if _, ok := getters[method.Name]; !ok {
-			continue
+		tag := parseMemberTag(mtags[method.Name], vt, method.Name)
+		_, isGetter := getters[method.Name]
+		setIndex, hasSetter := setters[method.Name]
+		if tag.readOnly {
+			if !isGetter {
+				panic(fmt.Sprintf("readonly tag on method %s.%s, which is not a getter", vt.Name(), method.Name))
+			}
+			hasSetter = false
+		} else if isGetter && !hasSetter {
+			// Getters without a setter are plain methods.
+			isGetter = false
 		}
-		if _, ok := setters[method.Name]; !ok {
-			delete(getters, method.Name)
+		if tag.hidden {
 			continue
 		}
-		// This is a getter method
-		names = appendLoweredName(names, method.Name)
-		names = append(names, 0)
-	}
-	for i := 0; i < numMethod; i++ {
-		method := vtptr.Method(i)
-		if method.PkgPath != "" {
-			continue // not exported
+		if tag.notify != "" && !isGetter {
+			panic(fmt.Sprintf("notify tag on method %s.%s, which is not a getter", vt.Name(), method.Name))
 		}
-		if _, ok := getters[method.Name]; ok {
-			continue // getter already handled above
+		member := memberSpec{
+			name:         tag.name,
+			reflectIndex: i,
+			getIndex:     -1,
+			setIndex:     -1,
+			readOnly:     tag.readOnly,
+			notify:       tag.notify,
+			revision:     tag.revision,
+			method:       method,
+		}
+		if member.name == "" {
+			member.name = string(appendLoweredName(nil, method.Name))
+		}
+		if isGetter {
+			// This is a getter method
+			member.dataType = dataTypeOf(method.Type.Out(0))
+			member.reflectIndex = -1
+			member.getIndex = i
+			if hasSetter {
+				member.setIndex = setIndex
+			}
+			fields = append(fields, member)
+		} else {
+			member.dataType = C.DTMethod
+			member.signature, member.result = methodQtSignature(method, tag.name)
+			methods = append(methods, member)
 		}
-		names = appendLoweredName(names, method.Name)
-		names = append(names, 0)
 	}
-	if len(names) != namesLen {
-		panic("pre-allocated buffer size was wrong")
+
+	// struct { FooBar T; Baz T } => "fooBar\0baz\0"
+	members := append(fields, methods...)
+	var names []byte
+	offsets := make([]int, len(members))
+	for i, member := range members {
+		offsets[i] = len(names)
+		names = append(names, member.name...)
+		names = append(names, 0)
 	}
 	typeInfo.memberNames = C.CString(string(names))
 
 	// Assemble information on members.
-	membersLen := numField - privateFields + numMethod - privateMethods
-	membersi := uintptr(0)
-	mnamesi := uintptr(0)
-	members := uintptr(C.malloc(memberInfoSize * C.size_t(membersLen)))
+	membersLen := len(members)
+	membersp := uintptr(C.malloc(memberInfoSize * C.size_t(membersLen)))
 	mnames := uintptr(unsafe.Pointer(typeInfo.memberNames))
-	for i := 0; i < numField; i++ {
-		field := vt.Field(i)
-		if field.PkgPath != "" {
-			continue // not exported
+	for i, member := range members {
+		memberInfo := (*C.GoMemberInfo)(unsafe.Pointer(membersp + uintptr(memberInfoSize)*uintptr(i)))
+		memberInfo.memberName = (*C.char)(unsafe.Pointer(mnames + uintptr(offsets[i])))
+		memberInfo.memberType = member.dataType
+		memberInfo.reflectIndex = C.int(member.reflectIndex)
+		memberInfo.reflectGetIndex = C.int(member.getIndex)
+		memberInfo.reflectSetIndex = C.int(member.setIndex)
+		memberInfo.addrOffset = C.int(member.addrOffset)
+		memberInfo.readOnly = 0
+		if member.readOnly {
+			memberInfo.readOnly = 1
 		}
-		memberInfo := (*C.GoMemberInfo)(unsafe.Pointer(members + uintptr(memberInfoSize)*membersi))
-		memberInfo.memberName = (*C.char)(unsafe.Pointer(mnames + mnamesi))
-		memberInfo.memberType = dataTypeOf(field.Type)
-		memberInfo.reflectIndex = C.int(i)
-		memberInfo.reflectGetIndex = -1
-		memberInfo.reflectSetIndex = -1
-		memberInfo.addrOffset = C.int(field.Offset)
-		membersi += 1
-		mnamesi += uintptr(len(field.Name)) + 1
-		if methodIndex, ok := setters[field.Name]; ok {
-			memberInfo.reflectSetIndex = C.int(methodIndex)
-		}
-	}
-	for i := 0; i < numMethod; i++ {
-		method := vtptr.Method(i)
-		if method.PkgPath != "" {
-			continue // not exported
+		memberInfo.revision = C.int(member.revision)
+		memberInfo.notifySignal = nilCharPtr
+		if member.notify != "" {
+			memberInfo.notifySignal = C.CString(member.notify)
 		}
-		if _, ok := getters[method.Name]; !ok {
-			continue // not a getter
-		}
-		memberInfo := (*C.GoMemberInfo)(unsafe.Pointer(members + uintptr(memberInfoSize)*membersi))
-		memberInfo.memberName = (*C.char)(unsafe.Pointer(mnames + mnamesi))
-		memberInfo.memberType = dataTypeOf(method.Type.Out(0))
-		memberInfo.reflectIndex = -1
-		memberInfo.reflectGetIndex = C.int(getters[method.Name])
-		memberInfo.reflectSetIndex = C.int(setters[method.Name])
-		memberInfo.addrOffset = 0
-		membersi += 1
-		mnamesi += uintptr(len(method.Name)) + 1
-	}
-	for i := 0; i < numMethod; i++ {
-		method := vtptr.Method(i)
-		if method.PkgPath != "" {
-			continue // not exported
+		memberInfo.methodSignature = nilCharPtr
+		memberInfo.resultSignature = nilCharPtr
+		if member.dataType != C.DTMethod {
+			continue
 		}
-		if _, ok := getters[method.Name]; ok {
-			continue // getter already handled above
-		}
-		memberInfo := (*C.GoMemberInfo)(unsafe.Pointer(members + uintptr(memberInfoSize)*membersi))
-		memberInfo.memberName = (*C.char)(unsafe.Pointer(mnames + mnamesi))
-		memberInfo.memberType = C.DTMethod
-		memberInfo.reflectIndex = C.int(i)
-		memberInfo.reflectGetIndex = -1
-		memberInfo.reflectSetIndex = -1
-		memberInfo.addrOffset = 0
-		signature, result := methodQtSignature(method)
+
+		method := member.method
 		// TODO The signature data might be embedded in the same array as the member names.
-		memberInfo.methodSignature = C.CString(signature)
-		memberInfo.resultSignature = C.CString(result)
+		memberInfo.methodSignature = C.CString(member.signature)
+		memberInfo.resultSignature = C.CString(member.result)
 		// TODO Sort out methods with a variable number of arguments.
 		// It's called while bound, so drop the receiver.
 		memberInfo.numIn = C.int(method.Type.NumIn() - 1)
 		memberInfo.numOut = C.int(method.Type.NumOut())
-		membersi += 1
-		mnamesi += uintptr(len(method.Name)) + 1
 
 		if method.Name == "Paint" && memberInfo.numIn == 1 && memberInfo.numOut == 0 && method.Type.In(1) == typePainter {
 			typeInfo.paint = memberInfo
 		}
+		if method.Name == "UpdatePaintNode" && memberInfo.numIn == 1 && memberInfo.numOut == 0 && method.Type.In(1) == typeSceneGraph {
+			typeInfo.updatePaintNode = memberInfo
+		}
+		if event, ok := inputEventMethods[method.Name]; ok && memberInfo.numIn == 1 && memberInfo.numOut == 0 && method.Type.In(1) == event.typ {
+			typeInfo.eventMethods[event.kind] = memberInfo.reflectIndex
+		}
+		if kind := hookMethodKind(method); kind >= 0 {
+			typeInfo.hookMethods[kind] = memberInfo.reflectIndex
+		}
+	}
+	if unsafe.Pointer(typeInfo.paint) != nilPtr && unsafe.Pointer(typeInfo.updatePaintNode) != nilPtr {
+		panic(fmt.Sprintf("type %s cannot have both Paint and UpdatePaintNode methods", vt))
 	}
-	typeInfo.members = (*C.GoMemberInfo)(unsafe.Pointer(members))
+	typeInfo.members = (*C.GoMemberInfo)(unsafe.Pointer(membersp))
 	typeInfo.membersLen = C.int(membersLen)
 
 	typeInfo.fields = typeInfo.members
-	typeInfo.fieldsLen = C.int(numField - privateFields + len(getters))
-	typeInfo.methods = (*C.GoMemberInfo)(unsafe.Pointer(members + uintptr(memberInfoSize)*uintptr(typeInfo.fieldsLen)))
-	typeInfo.methodsLen = C.int(numMethod - privateMethods - len(getters))
+	typeInfo.fieldsLen = C.int(len(fields))
+	typeInfo.methods = (*C.GoMemberInfo)(unsafe.Pointer(membersp + uintptr(memberInfoSize)*uintptr(typeInfo.fieldsLen)))
+	typeInfo.methodsLen = C.int(len(methods))
+
+	typeInfoCache[vt] = typeInfo
+	return typeInfo
+}
+
+// copyTypeInfo returns a copy of typeInfo that may hold details specific
+// to a registered type, as the original is shared by all values of the Go type.
+func copyTypeInfo(typeInfo *C.GoTypeInfo) *C.GoTypeInfo {
+	info := (*C.GoTypeInfo)(C.malloc(typeInfoSize))
+	*info = *typeInfo
+	info.metaObject = nilPtr
+	return info
+}
 
-	if int(membersi) != membersLen {
-		panic("used more space than allocated for member names")
+// defaultPropertyName returns the name the []qml.Object field with the
+// provided Go name is exposed to QML as within typeInfo.
+func defaultPropertyName(typeInfo *C.GoTypeInfo, t reflect.Type, fieldName string) (string, error) {
+	field, ok := t.FieldByName(fieldName)
+	if !ok || len(field.Index) != 1 {
+		return "", fmt.Errorf("TypeSpec.DefaultProperty refers to unknown field %s.%s", t.Name(), fieldName)
 	}
-	if int(mnamesi) != namesLen {
-		panic("allocated buffer doesn't match used space")
+	if field.Type != typeObjSlice {
+		return "", fmt.Errorf("TypeSpec.DefaultProperty field %s.%s must have type []qml.Object, got %s", t.Name(), fieldName, field.Type)
 	}
-	if typeInfo.fieldsLen+typeInfo.methodsLen != typeInfo.membersLen {
-		panic("lengths are inconsistent")
+	fieldsp := uintptr(unsafe.Pointer(typeInfo.fields))
+	for i := 0; i < int(typeInfo.fieldsLen); i++ {
+		memberInfo := (*C.GoMemberInfo)(unsafe.Pointer(fieldsp + uintptr(memberInfoSize)*uintptr(i)))
+		if int(memberInfo.reflectIndex) == field.Index[0] && memberInfo.memberType == C.DTListProperty {
+			return C.GoString(memberInfo.memberName), nil
+		}
 	}
-
-	typeInfoCache[vt] = typeInfo
-	return typeInfo
+	return "", fmt.Errorf("TypeSpec.DefaultProperty field %s.%s is not visible to QML", t.Name(), fieldName)
 }
 
-func methodQtSignature(method reflect.Method) (signature, result string) {
+// methodQtSignature returns the Qt signature and result type for method.
+// The method name in the signature is the provided one, if not empty.
+func methodQtSignature(method reflect.Method, name string) (signature, result string) {
 	var buf bytes.Buffer
-	for i, rune := range method.Name {
-		if i == 0 {
-			buf.WriteRune(unicode.ToLower(rune))
-		} else {
-			buf.WriteString(method.Name[i:])
-			break
+	if name != "" {
+		buf.WriteString(name)
+	} else {
+		for i, rune := range method.Name {
+			if i == 0 {
+				buf.WriteRune(unicode.ToLower(rune))
+			} else {
+				buf.WriteString(method.Name[i:])
+				break
+			}
 		}
 	}
 	buf.WriteByte('(')
@@ -529,3 +616,11 @@ func unsafeStringData(s string) (*C.char, C.int) {
 func unsafeBytesData(b []byte) (*C.char, C.int) {
 	return *(**C.char)(unsafe.Pointer(&b)), C.int(len(b))
 }
+
+// cbool returns 1 if b is true, and 0 otherwise.
+func cbool(b bool) C.int {
+	if b {
+		return 1
+	}
+	return 0
+}
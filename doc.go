@@ -171,6 +171,27 @@
 // Inside QML logic, the getter and setter pair is seen as a single object property.
 //
 //
+// Struct tags
+//
+// The way fields are exposed to QML may be tuned via qml struct tags. The first
+// tag option renames the field, and the remaining ones may hide it, prevent QML
+// from changing it, define the name of the signal emitted when qml.Changed
+// reports a change on it, or define the revision of registered types that
+// first exposes it (see TypeSpec.Revision):
+//
+//    type Person struct {
+//            Name   string `qml:"fullName"`
+//            Age    int    `qml:",readonly,notify=ageChanged"`
+//            Email  string `qml:",revision=1"`
+//            Cache  []byte `qml:"-"`
+//            Secret string `qml:",hidden"`
+//    }
+//
+// Methods may be tagged similarly via the TagMethods function. A tagged getter
+// method may also be defined as readonly, in which case it is exposed as a
+// property even without a respective setter.
+//
+//
 // Painting
 //
 // Custom types implemented in Go may have displayable content by defining
@@ -184,6 +205,72 @@
 //
 //   https://github.com/go-qml/qml/tree/v1/examples/painting
 //
+// Content may also be drawn without OpenGL, via the 2D drawing methods
+// of Painter, which support paths, pens, gradients, text, images,
+// clipping and transformations:
+//
+//    func (p *Person) Paint(painter *qml.Painter) {
+//            painter.SetRenderHint(qml.Antialiasing, true)
+//            painter.SetPen(qml.Pen{Color: p.Color, Width: 2})
+//            painter.DrawEllipse(0, 0, 100, 100)
+//            painter.DrawTextRect(0, 0, 100, 100, qml.AlignCenter, p.Name)
+//    }
+//
+// Alternatively, custom types may build their content out of scene graph
+// nodes, which are retained across updates and rendered by Qt itself, by
+// defining an UpdatePaintNode method such as:
+//
+//    func (p *Person) UpdatePaintNode(sg *qml.SceneGraph) {
+//            if sg.Len() == 0 {
+//                    sg.AddGeometry(qml.DrawTriangles, p.Color, p.Vertices())
+//            }
+//    }
+//
+// See the SceneGraph type for details.
+//
+//
+// Input events
+//
+// Custom types with displayable content may handle mouse, hover, wheel,
+// touch, keyboard, and focus events by defining the respective methods,
+// such as:
+//
+//    func (p *Person) MousePressEvent(e *qml.MouseEvent) {
+//            if e.Button != qml.LeftButton {
+//                    e.Ignore()
+//                    return
+//            }
+//            p.Selected = true
+//            qml.Changed(p, &p.Selected)
+//    }
+//
+// The recognized methods are MousePressEvent, MouseReleaseEvent, MouseMoveEvent,
+// MouseDoubleClickEvent, HoverEnterEvent, HoverMoveEvent, HoverLeaveEvent,
+// WheelEvent, TouchEvent, KeyPressEvent, KeyReleaseEvent, FocusInEvent, and
+// FocusOutEvent. Key events are only delivered to the item that has the
+// active focus, which may be requested by setting its focus property in
+// QML, or by calling its forceActiveFocus method. Key events ignored by
+// the Go method are then handed to the Keys handlers declared in QML for
+// the item, such as Keys.onPressed, while the ones accepted are not.
+//
+//
+// Item lifecycle
+//
+// Registered Go types may observe the creation of their QML objects by
+// defining ClassBegin and ComponentComplete methods, which are called
+// before and after the initial property values declared in QML are
+// assigned, respectively. Types with displayable content may also observe
+// changes to their geometry and other item details:
+//
+//    func (p *Person) ComponentComplete()                         { ... }
+//    func (p *Person) GeometryChanged(newGeometry, oldGeometry qml.Rect) { ... }
+//    func (p *Person) ItemChanged(change qml.ItemChange)          { ... }
+//
+// Such types may also inform layouts of the size their content needs by
+// setting the implicitWidth and implicitHeight properties of their object:
+//
+//    obj.Set("implicitWidth", 120)
+//
 //
 // Packing resources into the Go qml binary
 //
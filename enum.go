@@ -0,0 +1,132 @@
+package qml
+
+// #include <stdlib.h>
+// #include "capi.h"
+import "C"
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"unsafe"
+)
+
+// enumTypes holds the Go types registered as QML enumerations, which are
+// handed to QML as integers. It must only be accessed from the main GUI thread.
+var enumTypes = make(map[reflect.Type]bool)
+
+var enumInfoSize = C.size_t(unsafe.Sizeof(C.GoEnumInfo{}))
+
+// enumSpec holds the details of a Go integer type exposed to QML as an enumeration.
+type enumSpec struct {
+	typ    reflect.Type
+	keys   []string
+	values []int64
+}
+
+func (e *enumSpec) Len() int { return len(e.keys) }
+
+func (e *enumSpec) Less(i, j int) bool {
+	if e.values[i] != e.values[j] {
+		return e.values[i] < e.values[j]
+	}
+	return e.keys[i] < e.keys[j]
+}
+
+func (e *enumSpec) Swap(i, j int) {
+	e.keys[i], e.keys[j] = e.keys[j], e.keys[i]
+	e.values[i], e.values[j] = e.values[j], e.values[i]
+}
+
+// parseEnum parses an enumeration provided as a map from constant names
+// to values of a named Go integer type, such as map[string]Status.
+func parseEnum(values interface{}) (*enumSpec, error) {
+	mv := reflect.ValueOf(values)
+	if mv.Kind() != reflect.Map || mv.Type().Key().Kind() != reflect.String {
+		return nil, fmt.Errorf("enumeration must be a map from constant names to values, got %T", values)
+	}
+	et := mv.Type().Elem()
+	switch et.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+	default:
+		return nil, fmt.Errorf("enumeration values must have an integer type, got %s", et)
+	}
+	if et.Name() == "" || et.PkgPath() == "" {
+		return nil, fmt.Errorf("enumeration values must have a named type, got %s", et)
+	}
+	spec := &enumSpec{typ: et}
+	for _, key := range mv.MapKeys() {
+		value := mv.MapIndex(key).Convert(typeInt64).Int()
+		if value < -1<<31 || value > 1<<31-1 {
+			return nil, fmt.Errorf("enumeration value %s.%s is out of range: %d", et.Name(), key.String(), mv.MapIndex(key).Interface())
+		}
+		spec.keys = append(spec.keys, key.String())
+		spec.values = append(spec.values, value)
+	}
+	sort.Sort(spec)
+	return spec, nil
+}
+
+// setTypeInfoEnums sets the enumerations held by info, which must not be
+// shared by other registered types (see copyTypeInfo).
+func setTypeInfoEnums(info *C.GoTypeInfo, enums []*enumSpec) {
+	enumsp := uintptr(C.malloc(enumInfoSize * C.size_t(len(enums))))
+	for i, enum := range enums {
+		enumInfo := (*C.GoEnumInfo)(unsafe.Pointer(enumsp + uintptr(enumInfoSize)*uintptr(i)))
+		var names []byte
+		for _, key := range enum.keys {
+			names = append(names, key...)
+			names = append(names, 0)
+		}
+		valuesp := uintptr(C.malloc(C.size_t(unsafe.Sizeof(C.int(0))) * C.size_t(len(enum.values))))
+		for j, value := range enum.values {
+			*(*C.int)(unsafe.Pointer(valuesp + unsafe.Sizeof(C.int(0))*uintptr(j))) = C.int(value)
+		}
+		enumInfo.enumName = C.CString(enum.typ.Name())
+		enumInfo.keyNames = C.CString(string(names))
+		enumInfo.values = (*C.int)(unsafe.Pointer(valuesp))
+		enumInfo.keysLen = C.int(len(enum.keys))
+	}
+
+	info.enums = (*C.GoEnumInfo)(unsafe.Pointer(enumsp))
+	info.enumsLen = C.int(len(enums))
+}
+
+// enumHolder is the Go type behind the QML types registered by RegisterEnum.
+type enumHolder struct {
+	_ int // Must not be zero-sized, so that values have distinct addresses.
+}
+
+// RegisterEnum registers the provided enumeration for use by QML code under
+// the name of its Go type, so that QML may refer to its constants as in
+// Status.Connected. The values must be provided as a map from the constant
+// names to values of a named Go integer type. For example:
+//
+//     qml.RegisterEnum("GoExtensions", 1, 0, map[string]Status{
+//         "Disconnected": Disconnected,
+//         "Connecting":   Connecting,
+//         "Connected":    Connected,
+//     })
+//
+// Once registered, values of the Go type are handed to QML as integers,
+// and integers provided by QML are converted back into the Go type when
+// assigned to fields or passed as method parameters.
+//
+// To expose an enumeration as part of a QML type backed by Go logic,
+// use the Enums field of TypeSpec instead.
+func RegisterEnum(location string, major, minor int, values interface{}) {
+	enum, err := parseEnum(values)
+	if err != nil {
+		panic(err)
+	}
+	err = registerType(location, major, minor, &TypeSpec{
+		Init:      func(*enumHolder, Object) {},
+		Name:      enum.typ.Name(),
+		Enums:     []interface{}{values},
+		Singleton: true,
+	})
+	if err != nil {
+		panic(err)
+	}
+}
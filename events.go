@@ -0,0 +1,269 @@
+package qml
+
+// #include <stdlib.h>
+// #include "capi.h"
+import "C"
+
+import (
+	"reflect"
+	"unsafe"
+)
+
+// MouseButton identifies mouse buttons. Multiple buttons may be combined.
+type MouseButton int
+
+const (
+	NoButton      MouseButton = 0x00
+	LeftButton    MouseButton = 0x01
+	RightButton   MouseButton = 0x02
+	MiddleButton  MouseButton = 0x04
+	BackButton    MouseButton = 0x08
+	ForwardButton MouseButton = 0x10
+)
+
+// KeyboardModifier identifies keyboard modifiers. Multiple modifiers may be combined.
+type KeyboardModifier int
+
+const (
+	NoModifier      KeyboardModifier = 0x00000000
+	ShiftModifier   KeyboardModifier = 0x02000000
+	ControlModifier KeyboardModifier = 0x04000000
+	AltModifier     KeyboardModifier = 0x08000000
+	MetaModifier    KeyboardModifier = 0x10000000
+	KeypadModifier  KeyboardModifier = 0x20000000
+)
+
+// Key identifies a keyboard key. Keys for letters and digits have
+// the value of the respective uppercase ASCII character, such as 'A'.
+type Key int
+
+const (
+	KeySpace     Key = 0x20
+	KeyEscape    Key = 0x01000000
+	KeyTab       Key = 0x01000001
+	KeyBacktab   Key = 0x01000002
+	KeyBackspace Key = 0x01000003
+	KeyReturn    Key = 0x01000004
+	KeyEnter     Key = 0x01000005
+	KeyInsert    Key = 0x01000006
+	KeyDelete    Key = 0x01000007
+	KeyPause     Key = 0x01000008
+	KeyHome      Key = 0x01000010
+	KeyEnd       Key = 0x01000011
+	KeyLeft      Key = 0x01000012
+	KeyUp        Key = 0x01000013
+	KeyRight     Key = 0x01000014
+	KeyDown      Key = 0x01000015
+	KeyPageUp    Key = 0x01000016
+	KeyPageDown  Key = 0x01000017
+)
+
+// TouchPointState defines the state of a touch point.
+type TouchPointState int
+
+const (
+	TouchPointPressed    TouchPointState = 0x01
+	TouchPointMoved      TouchPointState = 0x02
+	TouchPointStationary TouchPointState = 0x04
+	TouchPointReleased   TouchPointState = 0x08
+)
+
+// FocusReason defines why an item gained or lost the keyboard focus.
+type FocusReason int
+
+const (
+	MouseFocusReason        FocusReason = 0
+	TabFocusReason          FocusReason = 1
+	BacktabFocusReason      FocusReason = 2
+	ActiveWindowFocusReason FocusReason = 3
+	PopupFocusReason        FocusReason = 4
+	ShortcutFocusReason     FocusReason = 5
+	MenuBarFocusReason      FocusReason = 6
+	OtherFocusReason        FocusReason = 7
+)
+
+// InputEvent holds the acceptance state shared by all input events.
+//
+// Events are accepted when handed to a Go method, and an accepted event is
+// not propagated any further. Ignoring an event allows it to be delivered
+// to the items underneath. Ignoring a mouse press event also prevents the
+// respective move and release events from being delivered to the item.
+type InputEvent struct {
+	accepted bool
+}
+
+// Accept marks the event as handled.
+func (e *InputEvent) Accept() { e.accepted = true }
+
+// Ignore marks the event as not handled, so it may be propagated further.
+func (e *InputEvent) Ignore() { e.accepted = false }
+
+// IsAccepted returns whether the event is marked as handled.
+func (e *InputEvent) IsAccepted() bool { return e.accepted }
+
+// MouseEvent is provided to the mouse event methods of Go types.
+// X and Y are in the item's coordinate system, and SceneX and SceneY
+// in the coordinate system of the window holding the item.
+type MouseEvent struct {
+	InputEvent
+	X, Y           float64
+	SceneX, SceneY float64
+	Button         MouseButton // The button that caused the event.
+	Buttons        MouseButton // The buttons held while the event happened.
+	Modifiers      KeyboardModifier
+}
+
+// HoverEvent is provided to the hover event methods of Go types.
+type HoverEvent struct {
+	InputEvent
+	X, Y           float64
+	SceneX, SceneY float64
+	Modifiers      KeyboardModifier
+}
+
+// WheelEvent is provided to the WheelEvent method of Go types.
+// The angle deltas are in eighths of a degree, and most mouse wheels
+// move in steps of 15 degrees, which is a delta of 120.
+type WheelEvent struct {
+	InputEvent
+	X, Y           float64
+	SceneX, SceneY float64
+	AngleDeltaX    float64
+	AngleDeltaY    float64
+	Buttons        MouseButton
+	Modifiers      KeyboardModifier
+}
+
+// TouchPoint holds the details of a single point in a touch event.
+type TouchPoint struct {
+	ID       int
+	State    TouchPointState
+	X, Y     float64
+	Pressure float64
+}
+
+// TouchEvent is provided to the TouchEvent method of Go types.
+type TouchEvent struct {
+	InputEvent
+	Points    []TouchPoint
+	Modifiers KeyboardModifier
+}
+
+// KeyEvent is provided to the key event methods of Go types.
+// Text holds the text generated by the key, if any.
+type KeyEvent struct {
+	InputEvent
+	Key        Key
+	Text       string
+	Modifiers  KeyboardModifier
+	AutoRepeat bool
+}
+
+// FocusEvent is provided to the focus event methods of Go types.
+type FocusEvent struct {
+	Reason FocusReason
+}
+
+var (
+	typeMouseEvent = reflect.TypeOf(&MouseEvent{})
+	typeHoverEvent = reflect.TypeOf(&HoverEvent{})
+	typeWheelEvent = reflect.TypeOf(&WheelEvent{})
+	typeTouchEvent = reflect.TypeOf(&TouchEvent{})
+	typeKeyEvent   = reflect.TypeOf(&KeyEvent{})
+	typeFocusEvent = reflect.TypeOf(&FocusEvent{})
+)
+
+// inputEventMethods maps the names of the methods handling input
+// events to the event kind and the parameter type they must take.
+var inputEventMethods = map[string]struct {
+	kind C.int
+	typ  reflect.Type
+}{
+	"MousePressEvent":       {C.EventMousePress, typeMouseEvent},
+	"MouseReleaseEvent":     {C.EventMouseRelease, typeMouseEvent},
+	"MouseMoveEvent":        {C.EventMouseMove, typeMouseEvent},
+	"MouseDoubleClickEvent": {C.EventMouseDoubleClick, typeMouseEvent},
+	"HoverEnterEvent":       {C.EventHoverEnter, typeHoverEvent},
+	"HoverMoveEvent":        {C.EventHoverMove, typeHoverEvent},
+	"HoverLeaveEvent":       {C.EventHoverLeave, typeHoverEvent},
+	"WheelEvent":            {C.EventWheel, typeWheelEvent},
+	"TouchEvent":            {C.EventTouch, typeTouchEvent},
+	"KeyPressEvent":         {C.EventKeyPress, typeKeyEvent},
+	"KeyReleaseEvent":       {C.EventKeyRelease, typeKeyEvent},
+	"FocusInEvent":          {C.EventFocusIn, typeFocusEvent},
+	"FocusOutEvent":         {C.EventFocusOut, typeFocusEvent},
+}
+
+var touchPointSize = uintptr(unsafe.Sizeof(C.GoTouchPoint{}))
+
+// newInputEvent returns the Go event equivalent to cevent, and the
+// acceptance state to report back once it's handled, if any.
+func newInputEvent(cevent *C.GoInputEvent) (event interface{}, input *InputEvent) {
+	accepted := InputEvent{cevent.accepted != 0}
+	switch cevent.kind {
+	case C.EventMousePress, C.EventMouseRelease, C.EventMouseMove, C.EventMouseDoubleClick:
+		e := &MouseEvent{
+			InputEvent: accepted,
+			X:          float64(cevent.x),
+			Y:          float64(cevent.y),
+			SceneX:     float64(cevent.sceneX),
+			SceneY:     float64(cevent.sceneY),
+			Button:     MouseButton(cevent.button),
+			Buttons:    MouseButton(cevent.buttons),
+			Modifiers:  KeyboardModifier(cevent.modifiers),
+		}
+		return e, &e.InputEvent
+	case C.EventHoverEnter, C.EventHoverMove, C.EventHoverLeave:
+		e := &HoverEvent{
+			InputEvent: accepted,
+			X:          float64(cevent.x),
+			Y:          float64(cevent.y),
+			SceneX:     float64(cevent.sceneX),
+			SceneY:     float64(cevent.sceneY),
+			Modifiers:  KeyboardModifier(cevent.modifiers),
+		}
+		return e, &e.InputEvent
+	case C.EventWheel:
+		e := &WheelEvent{
+			InputEvent:  accepted,
+			X:           float64(cevent.x),
+			Y:           float64(cevent.y),
+			SceneX:      float64(cevent.sceneX),
+			SceneY:      float64(cevent.sceneY),
+			AngleDeltaX: float64(cevent.angleDeltaX),
+			AngleDeltaY: float64(cevent.angleDeltaY),
+			Buttons:     MouseButton(cevent.buttons),
+			Modifiers:   KeyboardModifier(cevent.modifiers),
+		}
+		return e, &e.InputEvent
+	case C.EventTouch:
+		e := &TouchEvent{
+			InputEvent: accepted,
+			Points:     make([]TouchPoint, int(cevent.touchPointsLen)),
+			Modifiers:  KeyboardModifier(cevent.modifiers),
+		}
+		for i := range e.Points {
+			cpoint := (*C.GoTouchPoint)(unsafe.Pointer(uintptr(unsafe.Pointer(cevent.touchPoints)) + uintptr(i)*touchPointSize))
+			e.Points[i] = TouchPoint{
+				ID:       int(cpoint.id),
+				State:    TouchPointState(cpoint.state),
+				X:        float64(cpoint.x),
+				Y:        float64(cpoint.y),
+				Pressure: float64(cpoint.pressure),
+			}
+		}
+		return e, &e.InputEvent
+	case C.EventKeyPress, C.EventKeyRelease:
+		e := &KeyEvent{
+			InputEvent: accepted,
+			Key:        Key(cevent.key),
+			Text:       C.GoStringN(cevent.text, cevent.textLen),
+			Modifiers:  KeyboardModifier(cevent.modifiers),
+			AutoRepeat: cevent.autoRepeat != 0,
+		}
+		return e, &e.InputEvent
+	case C.EventFocusIn, C.EventFocusOut:
+		return &FocusEvent{Reason: FocusReason(cevent.reason)}, nil
+	}
+	panic("unknown input event kind")
+}
@@ -0,0 +1,85 @@
+package qml
+
+// #include <stdlib.h>
+// #include "capi.h"
+import "C"
+
+import (
+	"reflect"
+	"unsafe"
+)
+
+// Rect holds the position and size of an item.
+type Rect struct {
+	X, Y, Width, Height float64
+}
+
+// ItemChangeKind defines the kind of change reported to ItemChanged methods.
+type ItemChangeKind int
+
+const (
+	ItemChildAdded        ItemChangeKind = 0 // Value holds the child Object.
+	ItemChildRemoved      ItemChangeKind = 1 // Value holds the child Object, or nil if the child is being destroyed; see ItemChange.
+	ItemWindowChange      ItemChangeKind = 2 // Value holds the window Object, or nil.
+	ItemVisibleChange     ItemChangeKind = 3 // Value holds the new visibility as a bool.
+	ItemParentChange      ItemChangeKind = 4 // Value holds the parent Object, or nil.
+	ItemOpacityChange     ItemChangeKind = 5 // Value holds the new opacity as a float64.
+	ItemActiveFocusChange ItemChangeKind = 6 // Value holds the new active focus state as a bool.
+	ItemRotationChange    ItemChangeKind = 7 // Value holds the new rotation as a float64.
+)
+
+// ItemChange describes a change in a Go-backed item, as provided to
+// ItemChanged methods. The content of Value depends on Kind.
+//
+// Objects are provided as an Object even when they hold a Go value.
+// They may be destroyed at any time after ItemChanged returns, so
+// they should not be retained.
+//
+// A child being destroyed is only recognized as such, and reported via
+// ItemChildRemoved with a nil Value, if its type is more specific than
+// a plain Item, as is the case for a Rectangle or a Go-backed type.
+// The destruction of a plain Item child, whether declared as Item in QML
+// or created as a QQuickItem in C++, cannot be told apart from a normal
+// removal, so such a child is provided as an Object that may already be
+// partially destroyed and must not be used.
+type ItemChange struct {
+	Kind  ItemChangeKind
+	Value interface{}
+}
+
+var (
+	typeRect       = reflect.TypeOf(Rect{})
+	typeItemChange = reflect.TypeOf(ItemChange{})
+)
+
+// hookMethods maps the names of the lifecycle hook methods to
+// their kind and the parameter types they must take.
+var hookMethods = map[string]struct {
+	kind C.int
+	in   []reflect.Type
+}{
+	"ClassBegin":        {C.HookClassBegin, nil},
+	"ComponentComplete": {C.HookComponentComplete, nil},
+	"GeometryChanged":   {C.HookGeometryChanged, []reflect.Type{typeRect, typeRect}},
+	"ItemChanged":       {C.HookItemChanged, []reflect.Type{typeItemChange}},
+}
+
+// hookMethodKind returns the kind of lifecycle hook implemented
+// by method, or -1 if it is not a lifecycle hook.
+func hookMethodKind(method reflect.Method) C.int {
+	hook, ok := hookMethods[method.Name]
+	if !ok || method.Type.NumOut() != 0 || method.Type.NumIn() != len(hook.in)+1 {
+		return -1
+	}
+	for i, t := range hook.in {
+		if method.Type.In(i+1) != t {
+			return -1
+		}
+	}
+	return hook.kind
+}
+
+func newRect(crect *C.double) Rect {
+	r := (*[4]C.double)(unsafe.Pointer(crect))
+	return Rect{float64(r[0]), float64(r[1]), float64(r[2]), float64(r[3])}
+}
@@ -9,6 +9,7 @@ import (
 	"log"
 	"path/filepath"
 	"strings"
+	"sync"
 )
 
 // SetLogger sets the target for messages logged by the qml package,
@@ -21,20 +22,26 @@ import (
 // If no logger is provided, the qml package will send messages to the
 // default log package logger. This behavior may also be restored by
 // providing a nil logger to this function.
+//
+// Messages are delivered to the logger one at a time, but not necessarily
+// from the main GUI thread. Warnings from the watchdog (see SetWatchdog),
+// for example, are delivered while the GUI thread is blocked. Messages
+// logged while the logger is running, including ones caused by the logger
+// itself, are delivered after it returns.
 func SetLogger(logger interface{}) {
+	var handler QmlLogger
 	if logger == nil {
-		logHandler = defaultLogger{}
-		return
-	}
-	if qmll, ok := logger.(QmlLogger); ok {
-		logHandler = qmll
-		return
+		handler = defaultLogger{}
+	} else if qmll, ok := logger.(QmlLogger); ok {
+		handler = qmll
+	} else if stdl, ok := logger.(StdLogger); ok {
+		handler = wrappedStdLogger{stdl}
+	} else {
+		panic("unsupported logger interface")
 	}
-	if stdl, ok := logger.(StdLogger); ok {
-		logHandler = wrappedStdLogger{stdl}
-		return
-	}
-	panic("unsupported logger interface")
+	logMutex.Lock()
+	logHandler = handler
+	logMutex.Unlock()
 }
 
 // The QmlLogger interface may be implemented to better control how
@@ -80,7 +87,56 @@ const (
 	LogFatal
 )
 
-var logHandler QmlLogger = defaultLogger{}
+var (
+	logHandler QmlLogger = defaultLogger{}
+	logMutex   sync.Mutex
+	logBusy    bool
+	logQueue   []LogMessage
+)
+
+// logOutput delivers msg to the current logger. Calls are serialized
+// so that loggers are never called concurrently, but the logger itself
+// runs without logMutex held so that it may use the qml package, or
+// log further messages. Messages logged while another one is being
+// delivered are copied and delivered by that same call once the
+// logger returns.
+func logOutput(msg LogMessage) {
+	logMutex.Lock()
+	if logBusy {
+		logQueue = append(logQueue, copyLogMessage(msg))
+		logMutex.Unlock()
+		return
+	}
+	logBusy = true
+	for {
+		handler := logHandler
+		logMutex.Unlock()
+		handler.QmlOutput(msg)
+		logMutex.Lock()
+		if len(logQueue) == 0 {
+			break
+		}
+		msg = logQueue[0]
+		logQueue[0] = nil
+		logQueue = logQueue[1:]
+	}
+	logBusy = false
+	logMutex.Unlock()
+}
+
+// copyLogMessage returns a copy of msg that remains valid after the
+// log hook returns.
+func copyLogMessage(msg LogMessage) LogMessage {
+	if m, ok := msg.(*goLogMessage); ok {
+		return m
+	}
+	return &goLogMessage{
+		severity: msg.Severity(),
+		text:     msg.Text(),
+		file:     msg.File(),
+		line:     msg.Line(),
+	}
+}
 
 type defaultLogger struct{}
 
@@ -102,7 +158,7 @@ func hookLogHandler(cmsg *C.LogMessage) {
 		return
 	}
 	msg := logMessage{c: cmsg}
-	logHandler.QmlOutput(&msg)
+	logOutput(&msg)
 	msg.invalid = true
 }
 
@@ -151,7 +207,7 @@ func (m *logMessage) File() string {
 
 func (m *logMessage) Text() string {
 	m.assertValid()
-	return C.GoStringN(m.c.text, m.c.line)
+	return C.GoStringN(m.c.text, m.c.textLen)
 }
 
 func (*logMessage) privateMarker() {}
@@ -0,0 +1,89 @@
+package qml
+
+import (
+	"bufio"
+	"expvar"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// PublishStats publishes the statistics collected by the qml package
+// under the given name via the expvar package, so that they're made
+// available at the /debug/vars HTTP endpoint and anywhere else expvar
+// variables are exported to. The published value is a snapshot as
+// returned by Stats at the time it's read.
+//
+// Statistics are only collected after CollectStats(true) is called.
+// Publishing the statistics again under the same name has no effect,
+// but PublishStats panics if the name is already in use by a variable
+// published elsewhere, as expvar.Publish does.
+func PublishStats(name string) {
+	publishedStatsMutex.Lock()
+	defer publishedStatsMutex.Unlock()
+	if publishedStats[name] {
+		return
+	}
+	expvar.Publish(name, expvar.Func(func() interface{} { return Stats() }))
+	publishedStats[name] = true
+}
+
+var publishedStats = make(map[string]bool)
+var publishedStatsMutex sync.Mutex
+
+type metric struct {
+	name  string
+	kind  string
+	help  string
+	value func(s *Statistics) float64
+}
+
+func seconds(d time.Duration) float64 { return d.Seconds() }
+
+var metrics = []metric{
+	{"engines_alive", "gauge", "Number of QML engines alive.",
+		func(s *Statistics) float64 { return float64(s.EnginesAlive) }},
+	{"values_alive", "gauge", "Number of Go values currently referenced by QML.",
+		func(s *Statistics) float64 { return float64(s.ValuesAlive) }},
+	{"connections_alive", "gauge", "Number of Go functions connected to QML signals.",
+		func(s *Statistics) float64 { return float64(s.ConnectionsAlive) }},
+	{"run_main_calls_total", "counter", "Functions handed over to the main GUI thread by RunMain.",
+		func(s *Statistics) float64 { return float64(s.RunMainCalls) }},
+	{"run_main_wait_seconds_total", "counter", "Time functions handed over by RunMain waited before running.",
+		func(s *Statistics) float64 { return seconds(s.RunMainWait) }},
+	{"run_main_max_wait_seconds", "gauge", "Longest time a function handed over by RunMain waited before running.",
+		func(s *Statistics) float64 { return seconds(s.RunMainMaxWait) }},
+	{"idle_iterations_total", "counter", "Times the idle timer fired in the main GUI thread.",
+		func(s *Statistics) float64 { return float64(s.IdleIterations) }},
+	{"signal_calls_total", "counter", "Signals delivered to Go functions.",
+		func(s *Statistics) float64 { return float64(s.SignalCalls) }},
+	{"image_requests_total", "counter", "Images requested from Go image providers.",
+		func(s *Statistics) float64 { return float64(s.ImageRequests) }},
+	{"image_request_seconds_total", "counter", "Time taken by Go image providers.",
+		func(s *Statistics) float64 { return seconds(s.ImageRequestTime) }},
+	{"paint_calls_total", "counter", "Calls made to Paint methods.",
+		func(s *Statistics) float64 { return float64(s.PaintCalls) }},
+	{"paint_seconds_total", "counter", "Time taken by Paint methods.",
+		func(s *Statistics) float64 { return seconds(s.PaintTime) }},
+}
+
+// WriteMetrics writes a snapshot of the statistics collected by the qml
+// package to w in the Prometheus text exposition format. All metric
+// names are prefixed with "qml_". For example:
+//
+//     # HELP qml_values_alive Number of Go values currently referenced by QML.
+//     # TYPE qml_values_alive gauge
+//     qml_values_alive 3
+//
+// Statistics are only collected after CollectStats(true) is called.
+func WriteMetrics(w io.Writer) error {
+	snapshot := Stats()
+	bw := bufio.NewWriter(w)
+	for _, m := range metrics {
+		fmt.Fprintf(bw, "# HELP qml_%s %s\n", m.name, m.help)
+		fmt.Fprintf(bw, "# TYPE qml_%s %s\n", m.name, m.kind)
+		fmt.Fprintf(bw, "qml_%s %g\n", m.name, m.value(&snapshot))
+	}
+	return bw.Flush()
+}
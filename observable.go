@@ -0,0 +1,81 @@
+package qml
+
+import (
+	"reflect"
+	"sync"
+)
+
+// Observable holds a value that automatically notifies QML when changed.
+//
+// When an Observable is an exported field of a struct handed to QML by
+// its address, the field is visible to QML as a property holding the
+// observable value, and setting the value via the Set method updates all
+// QML bindings depending on the property, as if Changed had been called.
+//
+// For example:
+//
+//     type Person struct {
+//         Name qml.Observable
+//     }
+//
+//     person := &Person{}
+//     context.SetVar("person", person)
+//     ...
+//     person.Name.Set("Bob") // QML bindings on person.name are updated.
+//
+// Observable values must not be copied after being handed to QML.
+type Observable struct {
+	mutex sync.Mutex
+	value interface{}
+	owner interface{}
+}
+
+// Get returns the value held by o.
+func (o *Observable) Get() interface{} {
+	o.mutex.Lock()
+	value := o.value
+	o.mutex.Unlock()
+	return value
+}
+
+// Set changes the value held by o, and notifies QML of the change
+// if o is a field of a value that was handed to QML.
+func (o *Observable) Set(value interface{}) {
+	o.mutex.Lock()
+	o.value = value
+	owner := o.owner
+	o.mutex.Unlock()
+	if owner != nil {
+		Changed(owner, o)
+	}
+}
+
+// observableFields holds the indexes of the Observable fields of each
+// struct type handed to QML. It must only be accessed from the main GUI thread.
+var observableFields = make(map[reflect.Type][]int)
+
+// attachObservables records gvalue as the owner of its Observable fields,
+// so that they may report changes on their own.
+func attachObservables(gvalue interface{}) {
+	v := reflect.ValueOf(gvalue)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return
+	}
+	v = v.Elem()
+	t := v.Type()
+	fields, ok := observableFields[t]
+	if !ok {
+		for i := 0; i < t.NumField(); i++ {
+			if field := t.Field(i); field.Type == typeObservable && field.PkgPath == "" {
+				fields = append(fields, i)
+			}
+		}
+		observableFields[t] = fields
+	}
+	for _, i := range fields {
+		o := v.Field(i).Addr().Interface().(*Observable)
+		o.mutex.Lock()
+		o.owner = gvalue
+		o.mutex.Unlock()
+	}
+}
@@ -0,0 +1,471 @@
+package qml
+
+// #include <stdlib.h>
+// #include "capi.h"
+import "C"
+
+import (
+	"fmt"
+	"gopkg.in/qml.v1/gl/glbase"
+	"image"
+	"image/color"
+	"unsafe"
+)
+
+// Painter is provided to Paint methods on Go types that have displayable content.
+//
+// Content may be painted either with OpenGL calls, via the packages under
+// gopkg.in/qml.v1/gl, or with the 2D drawing methods of Painter itself.
+// Both may be mixed within the same Paint method, as long as GLContext is
+// called before OpenGL calls that follow 2D drawing.
+//
+// A Painter must not be used after the Paint method it was provided to returns.
+type Painter struct {
+	engine  *Engine
+	obj     Object
+	glctxt  glbase.Context
+	painter unsafe.Pointer
+	native  bool
+}
+
+// Object returns the underlying object being painted.
+func (p *Painter) Object() Object {
+	return p.obj
+}
+
+// GLContext returns the OpenGL context for this painter.
+//
+// It also prepares the painter for OpenGL calls following any
+// 2D drawing performed with the methods of p.
+func (p *Painter) GLContext() *glbase.Context {
+	p.beginNative()
+	return &p.glctxt
+}
+
+func (p *Painter) beginNative() {
+	if !p.native {
+		C.painterBeginNativePainting(p.painter)
+		p.native = true
+	}
+}
+
+func (p *Painter) endNative() {
+	if p.native {
+		C.painterEndNativePainting(p.painter)
+		p.native = false
+	}
+}
+
+// RenderHint defines a hint for the painting engine.
+type RenderHint int
+
+const (
+	Antialiasing          RenderHint = 0x01
+	TextAntialiasing      RenderHint = 0x02
+	SmoothPixmapTransform RenderHint = 0x04
+)
+
+// PenStyle defines how lines are drawn by a pen.
+type PenStyle int
+
+const (
+	SolidLine PenStyle = iota
+	NoPen
+	DashLine
+	DotLine
+	DashDotLine
+	DashDotDotLine
+)
+
+// PenCapStyle defines how the end points of lines are drawn by a pen.
+type PenCapStyle int
+
+const (
+	FlatCap PenCapStyle = iota
+	SquareCap
+	RoundCap
+)
+
+// PenJoinStyle defines how joins between lines are drawn by a pen.
+type PenJoinStyle int
+
+const (
+	MiterJoin PenJoinStyle = iota
+	BevelJoin
+	RoundJoin
+)
+
+// Pen defines how lines and outlines of shapes are drawn.
+// A Width of zero draws lines that are one pixel wide regardless
+// of the transformation in place.
+type Pen struct {
+	Color color.RGBA
+	Width float64
+	Style PenStyle
+	Cap   PenCapStyle
+	Join  PenJoinStyle
+}
+
+// GradientStop defines the color of a gradient at a position between 0 and 1.
+type GradientStop struct {
+	Pos   float64
+	Color color.RGBA
+}
+
+// LinearGradient is a brush that interpolates colors between
+// the start point (X1, Y1) and the final point (X2, Y2).
+type LinearGradient struct {
+	X1, Y1, X2, Y2 float64
+	Stops          []GradientStop
+}
+
+// RadialGradient is a brush that interpolates colors between the focal
+// point (FX, FY) and the circle with the provided center and radius.
+type RadialGradient struct {
+	CX, CY, Radius float64
+	FX, FY         float64
+	Stops          []GradientStop
+}
+
+// Font defines the font text is drawn with. If PixelSize is non-zero it
+// takes precedence over PointSize, and if both are zero the default size
+// is used.
+type Font struct {
+	Family    string
+	PointSize float64
+	PixelSize int
+	Bold      bool
+	Italic    bool
+}
+
+// Alignment defines how text is aligned within a rectangle.
+type Alignment int
+
+const (
+	AlignLeft    Alignment = 0x0001
+	AlignRight   Alignment = 0x0002
+	AlignHCenter Alignment = 0x0004
+	AlignJustify Alignment = 0x0008
+	AlignTop     Alignment = 0x0020
+	AlignBottom  Alignment = 0x0040
+	AlignVCenter Alignment = 0x0080
+	AlignCenter            = AlignHCenter | AlignVCenter
+
+	// TextWordWrap breaks lines at word boundaries when necessary.
+	TextWordWrap Alignment = 0x1000
+)
+
+// Path holds a sequence of drawing operations, such as lines and curves,
+// which may be drawn, filled, or used for clipping by a Painter.
+// The zero value is an empty path ready to use.
+type Path struct {
+	ops    []C.int
+	coords []C.double
+}
+
+func (path *Path) add(op C.int, coords ...float64) *Path {
+	path.ops = append(path.ops, op)
+	for _, c := range coords {
+		path.coords = append(path.coords, C.double(c))
+	}
+	return path
+}
+
+// MoveTo starts a new subpath at the provided point.
+func (path *Path) MoveTo(x, y float64) *Path {
+	return path.add(C.PathMoveTo, x, y)
+}
+
+// LineTo adds a straight line from the current point to the provided point.
+func (path *Path) LineTo(x, y float64) *Path {
+	return path.add(C.PathLineTo, x, y)
+}
+
+// QuadTo adds a quadratic Bézier curve from the current point to
+// the end point, using the provided control point.
+func (path *Path) QuadTo(cx, cy, x, y float64) *Path {
+	return path.add(C.PathQuadTo, cx, cy, x, y)
+}
+
+// CubicTo adds a cubic Bézier curve from the current point to
+// the end point, using the two provided control points.
+func (path *Path) CubicTo(c1x, c1y, c2x, c2y, x, y float64) *Path {
+	return path.add(C.PathCubicTo, c1x, c1y, c2x, c2y, x, y)
+}
+
+// ArcTo adds an arc of the ellipse that fits the provided rectangle,
+// starting at startAngle and spanning sweepLength degrees
+// counter-clockwise. A line is added from the current point to the
+// start of the arc, if necessary.
+func (path *Path) ArcTo(x, y, width, height, startAngle, sweepLength float64) *Path {
+	return path.add(C.PathArcTo, x, y, width, height, startAngle, sweepLength)
+}
+
+// Close closes the current subpath with a line to its start point.
+func (path *Path) Close() *Path {
+	return path.add(C.PathClose)
+}
+
+// AddRect adds the provided rectangle as a closed subpath.
+func (path *Path) AddRect(x, y, width, height float64) *Path {
+	return path.add(C.PathAddRect, x, y, width, height)
+}
+
+// AddRoundedRect adds the provided rectangle with rounded corners
+// as a closed subpath.
+func (path *Path) AddRoundedRect(x, y, width, height, xRadius, yRadius float64) *Path {
+	return path.add(C.PathAddRoundedRect, x, y, width, height, xRadius, yRadius)
+}
+
+// AddEllipse adds the ellipse that fits the provided rectangle
+// as a closed subpath.
+func (path *Path) AddEllipse(x, y, width, height float64) *Path {
+	return path.add(C.PathAddEllipse, x, y, width, height)
+}
+
+func (path *Path) data() (ops *C.int, opsLen C.int, coords *C.double) {
+	if len(path.ops) == 0 {
+		return nil, 0, nil
+	}
+	ops = &path.ops[0]
+	if len(path.coords) > 0 {
+		coords = &path.coords[0]
+	}
+	return ops, C.int(len(path.ops)), coords
+}
+
+// Save saves the current painter state, including the pen, brush, font,
+// transformation and clipping, so it may be restored by a later call to Restore.
+func (p *Painter) Save() {
+	p.endNative()
+	C.painterSave(p.painter)
+}
+
+// Restore restores the painter state saved by the last call to Save.
+func (p *Painter) Restore() {
+	p.endNative()
+	C.painterRestore(p.painter)
+}
+
+// SetRenderHint enables or disables the provided rendering hint.
+func (p *Painter) SetRenderHint(hint RenderHint, on bool) {
+	p.endNative()
+	C.painterSetRenderHint(p.painter, C.int(hint), cbool(on))
+}
+
+// SetOpacity sets the opacity of further drawing, between 0 and 1.
+func (p *Painter) SetOpacity(opacity float64) {
+	p.endNative()
+	C.painterSetOpacity(p.painter, C.double(opacity))
+}
+
+// SetPen sets the pen used to draw lines and outlines of shapes.
+func (p *Painter) SetPen(pen Pen) {
+	if pen.Style < SolidLine || pen.Style > DashDotDotLine {
+		panic(fmt.Sprintf("invalid pen style: %d", pen.Style))
+	}
+	if pen.Cap < FlatCap || pen.Cap > RoundCap {
+		panic(fmt.Sprintf("invalid pen cap style: %d", pen.Cap))
+	}
+	if pen.Join < MiterJoin || pen.Join > RoundJoin {
+		panic(fmt.Sprintf("invalid pen join style: %d", pen.Join))
+	}
+	p.endNative()
+	C.painterSetPen(p.painter, colorRGBA(pen.Color), C.double(pen.Width), C.int(pen.Style), C.int(pen.Cap), C.int(pen.Join))
+}
+
+// SetBrush sets the brush used to fill shapes. The brush may be nil for
+// no filling, a color.RGBA for a solid color, or a *LinearGradient or
+// *RadialGradient.
+func (p *Painter) SetBrush(brush interface{}) {
+	var kind C.int
+	var c C.uint
+	var coords []C.double
+	var stops []GradientStop
+	switch b := brush.(type) {
+	case nil:
+		kind = C.BrushNone
+	case color.RGBA:
+		kind = C.BrushColor
+		c = colorRGBA(b)
+	case *LinearGradient:
+		kind = C.BrushLinearGradient
+		coords = []C.double{C.double(b.X1), C.double(b.Y1), C.double(b.X2), C.double(b.Y2)}
+		stops = b.Stops
+	case *RadialGradient:
+		kind = C.BrushRadialGradient
+		coords = []C.double{C.double(b.CX), C.double(b.CY), C.double(b.Radius), C.double(b.FX), C.double(b.FY)}
+		stops = b.Stops
+	default:
+		panic(fmt.Sprintf("unsupported brush type: %T", brush))
+	}
+	var ccoords *C.double
+	if len(coords) > 0 {
+		ccoords = &coords[0]
+	}
+	var cpositions *C.double
+	var ccolors *C.uint
+	if len(stops) > 0 {
+		positions := make([]C.double, len(stops))
+		colors := make([]C.uint, len(stops))
+		for i, stop := range stops {
+			positions[i] = C.double(stop.Pos)
+			colors[i] = colorRGBA(stop.Color)
+		}
+		cpositions = &positions[0]
+		ccolors = &colors[0]
+	}
+	p.endNative()
+	C.painterSetBrush(p.painter, kind, c, ccoords, cpositions, ccolors, C.int(len(stops)))
+}
+
+// SetFont sets the font used to draw text.
+func (p *Painter) SetFont(font Font) {
+	cfamily, cfamilyLen := unsafeStringData(font.Family)
+	p.endNative()
+	C.painterSetFont(p.painter, cfamily, cfamilyLen, C.double(font.PointSize), C.int(font.PixelSize), cbool(font.Bold), cbool(font.Italic))
+}
+
+// Translate moves the coordinate system by the provided offset.
+func (p *Painter) Translate(dx, dy float64) {
+	p.endNative()
+	C.painterTranslate(p.painter, C.double(dx), C.double(dy))
+}
+
+// Rotate rotates the coordinate system clockwise by angle degrees.
+func (p *Painter) Rotate(angle float64) {
+	p.endNative()
+	C.painterRotate(p.painter, C.double(angle))
+}
+
+// Scale scales the coordinate system by the provided factors.
+func (p *Painter) Scale(sx, sy float64) {
+	p.endNative()
+	C.painterScale(p.painter, C.double(sx), C.double(sy))
+}
+
+// ResetTransform resets any translation, rotation, and scaling in place.
+func (p *Painter) ResetTransform() {
+	p.endNative()
+	C.painterResetTransform(p.painter)
+}
+
+// SetClipRect restricts further drawing to the provided rectangle.
+func (p *Painter) SetClipRect(x, y, width, height float64) {
+	p.endNative()
+	C.painterSetClipRect(p.painter, C.double(x), C.double(y), C.double(width), C.double(height))
+}
+
+// SetClipPath restricts further drawing to the area within path.
+func (p *Painter) SetClipPath(path *Path) {
+	ops, opsLen, coords := path.data()
+	p.endNative()
+	C.painterSetClipPath(p.painter, ops, opsLen, coords)
+}
+
+// SetClipping enables or disables clipping.
+func (p *Painter) SetClipping(enabled bool) {
+	p.endNative()
+	C.painterSetClipping(p.painter, cbool(enabled))
+}
+
+// DrawLine draws a line with the current pen.
+func (p *Painter) DrawLine(x1, y1, x2, y2 float64) {
+	p.endNative()
+	C.painterDrawLine(p.painter, C.double(x1), C.double(y1), C.double(x2), C.double(y2))
+}
+
+// DrawRect draws a rectangle outlined with the current pen and
+// filled with the current brush.
+func (p *Painter) DrawRect(x, y, width, height float64) {
+	p.endNative()
+	C.painterDrawRect(p.painter, C.double(x), C.double(y), C.double(width), C.double(height), 0, 0)
+}
+
+// DrawRoundedRect draws a rectangle with rounded corners outlined with
+// the current pen and filled with the current brush.
+func (p *Painter) DrawRoundedRect(x, y, width, height, xRadius, yRadius float64) {
+	p.endNative()
+	C.painterDrawRect(p.painter, C.double(x), C.double(y), C.double(width), C.double(height), C.double(xRadius), C.double(yRadius))
+}
+
+// DrawEllipse draws the ellipse that fits the provided rectangle, outlined
+// with the current pen and filled with the current brush.
+func (p *Painter) DrawEllipse(x, y, width, height float64) {
+	p.endNative()
+	C.painterDrawEllipse(p.painter, C.double(x), C.double(y), C.double(width), C.double(height))
+}
+
+// DrawPath draws path outlined with the current pen and filled with
+// the current brush.
+func (p *Painter) DrawPath(path *Path) {
+	ops, opsLen, coords := path.data()
+	p.endNative()
+	C.painterDrawPath(p.painter, ops, opsLen, coords)
+}
+
+// FillRect fills the provided rectangle with brush, without outlining it.
+// See SetBrush for the supported brush values.
+func (p *Painter) FillRect(x, y, width, height float64, brush interface{}) {
+	p.Save()
+	defer p.Restore()
+	p.SetPen(Pen{Style: NoPen})
+	p.SetBrush(brush)
+	p.DrawRect(x, y, width, height)
+}
+
+// FillPath fills path with brush, without outlining it.
+// See SetBrush for the supported brush values.
+func (p *Painter) FillPath(path *Path, brush interface{}) {
+	p.Save()
+	defer p.Restore()
+	p.SetPen(Pen{Style: NoPen})
+	p.SetBrush(brush)
+	p.DrawPath(path)
+}
+
+// StrokePath outlines path with pen, without filling it.
+func (p *Painter) StrokePath(path *Path, pen Pen) {
+	p.Save()
+	defer p.Restore()
+	p.SetPen(pen)
+	p.SetBrush(nil)
+	p.DrawPath(path)
+}
+
+// DrawText draws text with the current pen and font, with the
+// baseline of its first character starting at the provided point.
+func (p *Painter) DrawText(x, y float64, text string) {
+	ctext, ctextLen := unsafeStringData(text)
+	p.endNative()
+	C.painterDrawText(p.painter, C.double(x), C.double(y), ctext, ctextLen)
+}
+
+// DrawTextRect draws text with the current pen and font within the
+// provided rectangle, aligned according to flags.
+func (p *Painter) DrawTextRect(x, y, width, height float64, flags Alignment, text string) {
+	ctext, ctextLen := unsafeStringData(text)
+	p.endNative()
+	C.painterDrawTextRect(p.painter, C.double(x), C.double(y), C.double(width), C.double(height), C.int(flags), ctext, ctextLen)
+}
+
+// TextSize returns the size text takes when drawn with the current font.
+func (p *Painter) TextSize(text string) (width, height float64) {
+	var cwidth, cheight C.double
+	ctext, ctextLen := unsafeStringData(text)
+	C.painterTextSize(p.painter, ctext, ctextLen, &cwidth, &cheight)
+	return float64(cwidth), float64(cheight)
+}
+
+// DrawImage draws a copy of img at its natural size, with its top-left
+// corner at the provided point.
+func (p *Painter) DrawImage(x, y float64, img image.Image) {
+	rect := img.Bounds()
+	p.DrawImageRect(x, y, float64(rect.Dx()), float64(rect.Dy()), img)
+}
+
+// DrawImageRect draws a copy of img scaled to fit the provided rectangle.
+func (p *Painter) DrawImageRect(x, y, width, height float64, img image.Image) {
+	p.endNative()
+	C.painterDrawImage(p.painter, C.double(x), C.double(y), C.double(width), C.double(height), newCImage(img))
+}
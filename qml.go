@@ -7,9 +7,9 @@ package qml
 import "C"
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"gopkg.in/qml.v1/gl/glbase"
 	"image"
 	"image/color"
 	"io"
@@ -18,7 +18,7 @@ import (
 	"path/filepath"
 	"reflect"
 	"strings"
-	"sync"
+	"time"
 	"unsafe"
 )
 
@@ -77,6 +77,45 @@ func (e *Engine) Destroy() {
 	}
 }
 
+// Singleton returns the Go value used by e as the singleton of the type
+// registered under name at location, creating it if QML code has not yet
+// accessed it. If the type was registered multiple times, as happens with
+// distinct module versions, the most recent registration is used.
+//
+// Singleton requires Qt 5.12 or later.
+func (e *Engine) Singleton(location, name string) interface{} {
+	e.assertValid()
+	var result interface{}
+	var found bool
+	var cerr *C.error
+	RunMain(func() {
+		var spec *TypeSpec
+		for i := len(types) - 1; i >= 0; i-- {
+			if types[i].Singleton && types[i].location == location && types[i].Name == name {
+				spec = types[i]
+				break
+			}
+		}
+		if spec == nil {
+			return
+		}
+		found = true
+		var cvalue unsafe.Pointer
+		if cerr = C.engineSingleton(e.addr, C.int(spec.typeId), &cvalue); cerr != nil {
+			return
+		}
+		var fold *valueFold
+		if cerr = C.objectGoAddr(cvalue, (*unsafe.Pointer)(unsafe.Pointer(&fold))); cerr == nil {
+			result = ensureEngine(e.addr, unsafe.Pointer(fold)).gvalue
+		}
+	})
+	if !found {
+		panic(fmt.Sprintf("no singleton type %s registered at %s", name, location))
+	}
+	cmust(cerr)
+	return result
+}
+
 // Load loads a new component with the provided location and with the
 // content read from r. The location informs the resource name for
 // logged messages, and its path is used to locate any other resources
@@ -85,6 +124,17 @@ func (e *Engine) Destroy() {
 // Once a component is loaded, component instances may be created from
 // the resulting object via its Create and CreateWindow methods.
 func (e *Engine) Load(location string, r io.Reader) (Object, error) {
+	return e.LoadContext(context.Background(), location, r)
+}
+
+// LoadContext works like Load, but gives up and returns ctx.Err()
+// once ctx is done. The component is discarded if it ends up being
+// loaded after that.
+func (e *Engine) LoadContext(ctx context.Context, location string, r io.Reader) (Object, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	var cdata *C.char
 	var cdatalen C.int
 
@@ -123,7 +173,7 @@ func (e *Engine) Load(location string, r io.Reader) (Object, error) {
 	var err error
 	cloc, cloclen := unsafeStringData(location)
 	comp := &Common{engine: e}
-	RunMain(func() {
+	ctxErr := runMain(ctx, func() {
 		// TODO The component's parent should probably be the engine.
 		comp.addr = C.newComponent(e.addr, nilPtr)
 		if qrc {
@@ -136,7 +186,12 @@ func (e *Engine) Load(location string, r io.Reader) (Object, error) {
 			err = errors.New(strings.TrimRight(C.GoString(message), "\n"))
 			C.free(unsafe.Pointer(message))
 		}
+	}, func() {
+		RunMainAsync(func() { C.delObjectLater(comp.addr) })
 	})
+	if ctxErr != nil {
+		return nil, ctxErr
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -149,8 +204,14 @@ func (e *Engine) Load(location string, r io.Reader) (Object, error) {
 // Once a component is loaded, component instances may be created from
 // the resulting object via its Create and CreateWindow methods.
 func (e *Engine) LoadFile(path string) (Object, error) {
+	return e.LoadFileContext(context.Background(), path)
+}
+
+// LoadFileContext works like LoadFile, but gives up and returns
+// ctx.Err() once ctx is done.
+func (e *Engine) LoadFileContext(ctx context.Context, path string) (Object, error) {
 	if strings.HasPrefix(path, "qrc:") {
-		return e.Load(path, nil)
+		return e.LoadContext(ctx, path, nil)
 	}
 	// TODO Test this.
 	f, err := os.Open(path)
@@ -158,7 +219,7 @@ func (e *Engine) LoadFile(path string) (Object, error) {
 		return nil, err
 	}
 	defer f.Close()
-	return e.Load(path, f)
+	return e.LoadContext(ctx, path, f)
 }
 
 // LoadString loads a component from the provided QML string.
@@ -171,6 +232,12 @@ func (e *Engine) LoadString(location, qml string) (Object, error) {
 	return e.Load(location, strings.NewReader(qml))
 }
 
+// LoadStringContext works like LoadString, but gives up and returns
+// ctx.Err() once ctx is done.
+func (e *Engine) LoadStringContext(ctx context.Context, location, qml string) (Object, error) {
+	return e.LoadContext(ctx, location, strings.NewReader(qml))
+}
+
 // Context returns the engine's root context.
 func (e *Engine) Context() *Context {
 	e.assertValid()
@@ -182,34 +249,147 @@ func (e *Engine) Context() *Context {
 	return &ctx
 }
 
-// TODO ObjectOf is probably still worth it, but turned out unnecessary
-//      for GL functionality. Test it properly before introducing it.
-
 // ObjectOf returns the QML Object representation of the provided Go value
-// within the e engine.
-//func (e *Engine) ObjectOf(value interface{}) Object {
-//	// TODO Would be good to preserve identity on the Go side. See unpackDataValue as well.
-//	return &Common{
-//		engine: e,
-//		addr:   wrapGoValue(e, value, cppOwner),
-//	}
-//}
-
-// Painter is provided to Paint methods on Go types that have displayable content.
-type Painter struct {
-	engine *Engine
-	obj    Object
-	glctxt glbase.Context
+// within the e engine. The resulting object may be used as any other QML
+// object, for example to call methods on it, to connect to its signals,
+// or to set it as a property of other objects.
+//
+// If value is already a QML object, it is returned unmodified. Otherwise
+// value must be a struct or a pointer to a struct, and the engine will hold
+// a reference to it in the same way done by Context.SetVar, so asking for
+// the object of the same value multiple times yields the same QML object.
+func (e *Engine) ObjectOf(value interface{}) Object {
+	e.assertValid()
+	if obj, ok := value.(Object); ok {
+		return obj
+	}
+	if value == nil || deref(reflect.ValueOf(value)).Kind() != reflect.Struct {
+		panic(fmt.Sprintf("cannot obtain QML object for non-struct value: %#v", value))
+	}
+	// TODO Would be good to preserve identity on the Go side. See unpackDataValue as well.
+	var obj Common
+	obj.engine = e
+	RunMain(func() {
+		obj.addr = wrapGoValue(e, value, cppOwner)
+	})
+	return &obj
 }
 
-// Object returns the underlying object being painted.
-func (p *Painter) Object() Object {
-	return p.obj
+// Ownership defines whether the QML engine may destroy an object once
+// it is no longer referenced by QML code. See Engine.SetObjectOwnership.
+type Ownership int
+
+const (
+	// CppOwnership prevents the engine from destroying the object.
+	// Go values handed to QML via Context.SetVar, Object.Set and
+	// Engine.ObjectOf have this ownership, and are only released
+	// when the engine is destroyed or Engine.Release is called.
+	CppOwnership Ownership = iota
+
+	// JavaScriptOwnership allows the engine garbage collector to
+	// destroy the object once QML code holds no references to it.
+	// Go values returned by methods called from QML code have this
+	// ownership.
+	JavaScriptOwnership
+)
+
+func (o Ownership) String() string {
+	switch o {
+	case CppOwnership:
+		return "CppOwnership"
+	case JavaScriptOwnership:
+		return "JavaScriptOwnership"
+	}
+	return fmt.Sprintf("Ownership(%d)", int(o))
+}
+
+// SetObjectOwnership changes the ownership of obj within the e engine.
+//
+// Objects representing Go values that are switched to JavaScriptOwnership
+// stop being held by the engine, so they are collected once QML code no
+// longer references them. Note that the garbage collector only considers
+// objects that were at some point handed to QML code.
+func (e *Engine) SetObjectOwnership(obj Object, ownership Ownership) {
+	e.assertValid()
+	if ownership != CppOwnership && ownership != JavaScriptOwnership {
+		panic(fmt.Sprintf("invalid object ownership: %s", ownership))
+	}
+	addr := obj.Common().addr
+	RunMain(func() {
+		fold := objectFold(addr)
+		if fold != nil && fold.engine == e {
+			// Go values held by the engine are parented to it, which
+			// would prevent the garbage collector from destroying them.
+			if ownership == JavaScriptOwnership {
+				if C.objectParent(addr) == e.addr {
+					C.objectSetParent(addr, nilPtr)
+				}
+				fold.owner = jsOwner
+			} else {
+				if C.objectParent(addr) == nilPtr {
+					C.objectSetParent(addr, e.addr)
+				}
+				fold.owner = cppOwner
+				moveFoldFirst(fold)
+			}
+		}
+		if ownership == JavaScriptOwnership {
+			C.engineSetOwnershipJS(e.addr, addr)
+		} else {
+			C.engineSetOwnershipCPP(e.addr, addr)
+		}
+	})
 }
 
-// GLContext returns the OpenGL context for this painter.
-func (p *Painter) GLContext() *glbase.Context {
-	return &p.glctxt
+// ObjectOwnership returns the current ownership of obj within the e engine.
+func (e *Engine) ObjectOwnership(obj Object) Ownership {
+	e.assertValid()
+	addr := obj.Common().addr
+	var ownership Ownership
+	RunMain(func() {
+		ownership = Ownership(C.engineObjectOwnership(e.addr, addr))
+	})
+	return ownership
+}
+
+// Release destroys the objects the e engine holds on behalf of the provided
+// Go value, as a consequence of it being handed to QML via Context.SetVar,
+// Object.Set, Engine.ObjectOf, and similar. Objects representing value that
+// are owned by the garbage collector are unaffected.
+//
+// Release allows long-lived engines to drop references to values that
+// would otherwise only be released when the engine is destroyed. The
+// objects are destroyed once control returns to the event loop, and
+// they must not be used by QML code anymore.
+func (e *Engine) Release(value interface{}) {
+	e.assertValid()
+	if t := reflect.TypeOf(value); t == nil || !t.Comparable() {
+		// Such values can't have been handed to QML in the first place.
+		return
+	}
+	RunMain(func() {
+		for fold := e.values[value]; fold != nil; fold = fold.next {
+			if fold.owner == cppOwner {
+				// Prevent wrapGoValue from handing out the dying object.
+				fold.owner = releasedOwner
+				C.delObjectLater(fold.cvalue)
+			}
+		}
+	})
+}
+
+// objectFold returns the fold of the Go value backing the object at addr,
+// or nil if the object is not backed by a Go value.
+//
+// This must be run from the main GUI thread.
+func objectFold(addr unsafe.Pointer) *valueFold {
+	var fold *valueFold
+	cerr := C.objectGoAddr(addr, (*unsafe.Pointer)(unsafe.Pointer(&fold)))
+	if cerr != nil {
+		C.free(unsafe.Pointer(cerr))
+		return nil
+	}
+	return fold
 }
 
 // AddImageProvider registers f to be called when an image is requested by QML code
@@ -249,14 +429,20 @@ func hookRequestImage(imageFunc unsafe.Pointer, cid *C.char, cidLen, cwidth, che
 	width := int(cwidth)
 	height := int(cheight)
 
+	start := time.Now()
 	img := f(id, width, height)
+	stats.imageRequest(time.Since(start))
 
-	var cimage unsafe.Pointer
+	return newCImage(img)
+}
 
+// newCImage returns a new QImage holding a copy of img. The caller
+// becomes responsible for deleting it.
+func newCImage(img image.Image) unsafe.Pointer {
 	rect := img.Bounds()
-	width = rect.Max.X - rect.Min.X
-	height = rect.Max.Y - rect.Min.Y
-	cimage = C.newImage(C.int(width), C.int(height))
+	width := rect.Max.X - rect.Min.X
+	height := rect.Max.Y - rect.Min.Y
+	cimage := C.newImage(C.int(width), C.int(height))
 
 	var cbits []byte
 	cbitsh := (*reflect.SliceHeader)((unsafe.Pointer)(&cbits))
@@ -292,7 +478,7 @@ type Context struct {
 //
 // The engine will hold a reference to the provided value, so it will
 // not be garbage collected until the engine is destroyed, even if the
-// value is unused or changed.
+// value is unused or changed. See Engine.Release for dropping it earlier.
 func (ctx *Context) SetVar(name string, value interface{}) {
 	cname, cnamelen := unsafeStringData(name)
 	RunMain(func() {
@@ -369,7 +555,10 @@ type Object interface {
 	Create(ctx *Context) Object
 	CreateWindow(ctx *Context) *Window
 	Destroy()
-	On(signal string, function interface{})
+	On(signal string, function interface{}) *Connection
+	Off(signal string)
+	Notify(signal string, ch interface{}, policy NotifyPolicy) *Connection
+	Watch(property string, function interface{}) *Connection
 }
 
 // List holds a QML list which may be converted to a Go slice of an
@@ -765,7 +954,47 @@ func (obj *Common) Destroy() {
 	})
 }
 
-var connectedFunction = make(map[*interface{}]bool)
+// connectedFunction holds the connection each function is part of.
+var connectedFunction = make(map[*interface{}]*Connection)
+
+// Connection represents a connection between a QML signal and a Go function.
+type Connection struct {
+	sender    unsafe.Pointer
+	signal    string
+	connector unsafe.Pointer
+
+	// disconnected is only accessed from the main GUI thread.
+	disconnected bool
+
+	// broken, if set, is run from the main GUI thread once the
+	// connection is broken, either explicitly or because the sender
+	// was destroyed.
+	broken func()
+}
+
+// Disconnect breaks the connection, so that the Go function is not called
+// anymore when the signal is emitted. It is safe to call Disconnect more
+// than once, and also after the sender object was destroyed.
+func (conn *Connection) Disconnect() {
+	RunMain(conn.disconnect)
+}
+
+func (conn *Connection) disconnect() {
+	if !conn.disconnected {
+		conn.markDisconnected()
+		C.delObjectLater(conn.connector)
+	}
+}
+
+func (conn *Connection) markDisconnected() {
+	if conn.disconnected {
+		return
+	}
+	conn.disconnected = true
+	if conn.broken != nil {
+		conn.broken()
+	}
+}
 
 // On connects the named signal from obj with the provided function, so that
 // when obj next emits that signal, the function is called with the parameters
@@ -776,68 +1005,315 @@ var connectedFunction = make(map[*interface{}]bool)
 // resepctive parameter types must match exactly or be conversible according
 // to normal Go rules.
 //
+// If the function is variadic, all the signal parameters are delivered,
+// and the ones beyond the fixed parameters are provided as the variadic
+// arguments. Values that are nil in QML are delivered as the zero value
+// of the respective parameter type.
+//
 // For example:
 //
 //     obj.On("clicked", func() { fmt.Println("obj got a click") })
 //
+// The signal may be selected by its full signature rather than just its
+// name, which is necessary to pick a specific overload of a signal:
+//
+//     obj.On("clicked(QQuickMouseEvent*)", func(event qml.Object) { ... })
+//
 // Note that Go uses the real signal name, rather than the one used when
 // defining QML signal handlers ("clicked" rather than "onClicked").
 //
+// The returned connection may be used to disconnect the function from
+// the signal. Otherwise the connection lasts until obj is destroyed.
+//
 // For more details regarding signals and QML see:
 //
 //     http://qt-project.org/doc/qt-5.0/qtqml/qml-qtquick2-connections.html
 //
-func (obj *Common) On(signal string, function interface{}) {
+func (obj *Common) On(signal string, function interface{}) *Connection {
 	funcv := reflect.ValueOf(function)
 	funct := funcv.Type()
 	if funcv.Kind() != reflect.Func {
 		panic("function provided to On is not a function or method")
 	}
-	if funct.NumIn() > C.MaxParams {
-		panic("function takes too many arguments")
+	argsLen := funct.NumIn()
+	if funct.IsVariadic() {
+		// Deliver all parameters, with at least the fixed ones.
+		argsLen = -funct.NumIn()
 	}
+	return obj.connect(signal, function, argsLen)
+}
+
+// connect connects the named signal from obj with function, which must be
+// either a function accepting argsLen parameters or a signalSink. If argsLen
+// is negative all the signal parameters are delivered, and the signal must
+// have at least -argsLen-1 of them.
+func (obj *Common) connect(signal string, function interface{}, argsLen int) *Connection {
 	csignal, csignallen := unsafeStringData(signal)
+	conn := &Connection{sender: obj.addr}
+	// Sinks hand parameters over to other goroutines, which would outlive
+	// the objects only valid while the signal is being handled.
+	var cvaluesOnly C.int
+	if _, ok := function.(signalSink); ok {
+		cvaluesOnly = 1
+	}
 	var cerr *C.error
 	RunMain(func() {
-		cerr = C.objectConnect(obj.addr, csignal, csignallen, obj.engine.addr, unsafe.Pointer(&function), C.int(funcv.Type().NumIn()))
+		var cname *C.char
+		cerr = C.objectConnect(obj.addr, csignal, csignallen, obj.engine.addr, unsafe.Pointer(&function), C.int(argsLen), cvaluesOnly, &conn.connector, &cname)
 		if cerr == nil {
-			connectedFunction[&function] = true
+			// Record the plain signal name even if a signature was provided.
+			conn.signal = C.GoString(cname)
+			C.free(unsafe.Pointer(cname))
+			connectedFunction[&function] = conn
 			stats.connectionsAlive(+1)
 		}
 	})
 	cmust(cerr)
+	return conn
+}
+
+// signalSink is connected to signals in place of a function to handle
+// the signal parameters directly.
+type signalSink func(args []interface{})
+
+// NotifyPolicy defines what happens when a signal is emitted and the
+// channel provided to Notify is not ready to receive the notification.
+type NotifyPolicy int
+
+const (
+	// NotifyBlock blocks the main GUI thread until the notification
+	// is received, so that no notifications are lost.
+	NotifyBlock NotifyPolicy = iota
+
+	// NotifyDrop drops the notification if the channel is not ready
+	// to receive it, so that the main GUI thread is never blocked.
+	NotifyDrop
+)
+
+// Notify connects the named signal from obj with the provided channel,
+// so that when obj next emits that signal a notification is sent to the
+// channel, instead of calling a function in the main GUI thread as On does.
+// That allows slow handling of signals to take place in other goroutines
+// without freezing the user interface.
+//
+// The notification sent depends on the channel element type:
+//
+//     chan struct{}     - no signal parameters are delivered
+//     chan []interface{} - all signal parameters are delivered
+//     chan T            - the first signal parameter is delivered as a T
+//
+// In the latter case the parameter type must match T exactly or be
+// conversible to it according to normal Go rules, and interface{} may
+// be used to accept any value.
+//
+// Object parameters cannot be delivered, since objects such as mouse events
+// are only valid while the signal is being handled. Notify panics if any of
+// the parameters delivered to the channel is an object.
+//
+// The policy defines what happens when the channel is not ready to receive
+// the notification. The channel must not be closed while connected.
+//
+// For example:
+//
+//     clicks := make(chan struct{}, 16)
+//     obj.Notify("clicked", clicks, qml.NotifyDrop)
+//     go func() {
+//         for range clicks {
+//             fmt.Println("obj got a click")
+//         }
+//     }()
+//
+func (obj *Common) Notify(signal string, ch interface{}, policy NotifyPolicy) *Connection {
+	chv := reflect.ValueOf(ch)
+	if chv.Kind() != reflect.Chan || chv.Type().ChanDir()&reflect.SendDir == 0 {
+		panic("value provided to Notify is not a channel that can be sent to")
+	}
+	if policy != NotifyBlock && policy != NotifyDrop {
+		panic(fmt.Sprintf("unknown notify policy: %d", policy))
+	}
+	elemt := chv.Type().Elem()
+
+	argsLen := 1
+	switch elemt {
+	case typeEmpty:
+		argsLen = 0
+	case typeIfaceSlice:
+		argsLen = -1
+	}
+	sink := signalSink(func(args []interface{}) {
+		var v reflect.Value
+		switch argsLen {
+		case 0:
+			v = reflect.Zero(elemt)
+		case 1:
+			v = signalParam(signal, 0, args[0], elemt)
+		default:
+			v = reflect.ValueOf(args)
+		}
+		if policy == NotifyDrop {
+			chv.TrySend(v)
+		} else {
+			chv.Send(v)
+		}
+	})
+	return obj.connect(signal, sink, argsLen)
+}
+
+// Off disconnects all Go functions connected to the named signal of obj,
+// including the ones connected via Watch to a property notify signal.
+// Connections are matched by signal name, whether they were made with
+// the name or with a full signature, so a signature provided to Off
+// also matches the connections to all overloads of the signal.
+func (obj *Common) Off(signal string) {
+	if i := strings.IndexByte(signal, '('); i >= 0 {
+		signal = signal[:i]
+	}
+	RunMain(func() {
+		for _, conn := range connectedFunction {
+			if conn.sender == obj.addr && conn.signal == signal {
+				conn.disconnect()
+			}
+		}
+	})
+}
+
+// Watch connects the notify signal of the named property of obj with the
+// provided function, so that when the property value changes the function
+// is called with the previous and the new values of the property.
+//
+// The provided function must accept two parameters of the same type, and
+// the property values must match that type exactly or be conversible to it
+// according to normal Go rules. Using interface{} as the parameter type
+// accepts any value.
+//
+// For example:
+//
+//     conn := obj.Watch("width", func(old, new int) {
+//         fmt.Println("width changed from", old, "to", new)
+//     })
+//     ...
+//     conn.Disconnect()
+//
+// Watch panics if the property does not exist or has no notify signal.
+func (obj *Common) Watch(property string, function interface{}) *Connection {
+	funcv := reflect.ValueOf(function)
+	if funcv.Kind() != reflect.Func {
+		panic("function provided to Watch is not a function or method")
+	}
+	funct := funcv.Type()
+	if funct.NumIn() != 2 || funct.In(0) != funct.In(1) {
+		panic("function provided to Watch must accept the old and new values as parameters of the same type")
+	}
+	valuet := funct.In(0)
+
+	var old reflect.Value
+	var handler interface{} = func() {
+		value := propertyValue(property, obj.Property(property), valuet)
+		funcv.Call([]reflect.Value{old, value})
+		old = value
+	}
+
+	cproperty, cpropertylen := unsafeStringData(property)
+	conn := &Connection{sender: obj.addr}
+	var cerr *C.error
+	RunMain(func() {
+		var csignal *C.char
+		cerr = C.objectConnectNotify(obj.addr, cproperty, cpropertylen, obj.engine.addr, unsafe.Pointer(&handler), &conn.connector, &csignal)
+		if cerr == nil {
+			conn.signal = C.GoString(csignal)
+			C.free(unsafe.Pointer(csignal))
+			old = propertyValue(property, obj.Property(property), valuet)
+			connectedFunction[&handler] = conn
+			stats.connectionsAlive(+1)
+		}
+	})
+	cmust(cerr)
+	return conn
+}
+
+// convertValue returns value converted to type t, and whether
+// the conversion was possible. A nil value converts to the zero
+// value of any type.
+func convertValue(value interface{}, t reflect.Type) (reflect.Value, bool) {
+	if value == nil {
+		return reflect.Zero(t), true
+	}
+	out := reflect.New(t).Elem()
+	if err := convertAndSet(out, reflect.ValueOf(value), reflect.Value{}); err != nil {
+		return reflect.Value{}, false
+	}
+	return out, true
+}
+
+func propertyValue(property string, value interface{}, t reflect.Type) reflect.Value {
+	v, ok := convertValue(value, t)
+	if !ok {
+		panic(fmt.Sprintf("cannot convert value of property %q from %T to %s; provided value: %#v",
+			property, value, t, value))
+	}
+	return v
+}
+
+func signalParam(signal string, index int, value interface{}, t reflect.Type) reflect.Value {
+	v, ok := convertValue(value, t)
+	if !ok {
+		panic(fmt.Sprintf("cannot convert parameter %d of signal %q from %T to %s; provided value: %#v",
+			index, signal, value, t, value))
+	}
+	return v
 }
 
 //export hookSignalDisconnect
 func hookSignalDisconnect(funcp unsafe.Pointer) {
-	before := len(connectedFunction)
-	delete(connectedFunction, (*interface{})(funcp))
-	if before == len(connectedFunction) {
+	conn, ok := connectedFunction[(*interface{})(funcp)]
+	if !ok {
 		panic("disconnecting unknown signal function")
 	}
+	conn.markDisconnected()
+	delete(connectedFunction, (*interface{})(funcp))
 	stats.connectionsAlive(-1)
 }
 
 //export hookSignalCall
-func hookSignalCall(enginep unsafe.Pointer, funcp unsafe.Pointer, args *C.DataValue) {
+func hookSignalCall(enginep unsafe.Pointer, funcp unsafe.Pointer, args *C.DataValue, argsLen C.int) {
 	engine := engines[enginep]
 	if engine == nil {
 		panic("signal called after engine was destroyed")
 	}
+	conn := connectedFunction[(*interface{})(funcp)]
+	if conn == nil || conn.disconnected {
+		// Disconnected, and the connector is pending deletion.
+		return
+	}
+	stats.signalCall()
+	if sink, ok := (*(*interface{})(funcp)).(signalSink); ok {
+		values := make([]interface{}, int(argsLen))
+		for i := range values {
+			arg := (*C.DataValue)(unsafe.Pointer(uintptr(unsafe.Pointer(args)) + uintptr(i)*dataValueSize))
+			values[i] = unpackDataValue(arg, engine)
+		}
+		sink(values)
+		return
+	}
 	funcv := reflect.ValueOf(*(*interface{})(funcp))
 	funct := funcv.Type()
 	numIn := funct.NumIn()
-	var params [C.MaxParams]reflect.Value
-	for i := 0; i < numIn; i++ {
-		arg := (*C.DataValue)(unsafe.Pointer(uintptr(unsafe.Pointer(args)) + uintptr(i)*dataValueSize))
-		param := reflect.ValueOf(unpackDataValue(arg, engine))
-		if paramt := funct.In(i); param.Type() != paramt {
-			// TODO Provide a better error message when this fails.
-			param = param.Convert(paramt)
+	fixed := numIn
+	if funct.IsVariadic() {
+		fixed--
+	}
+	params := make([]reflect.Value, int(argsLen))
+	for i := range params {
+		var paramt reflect.Type
+		if i < fixed {
+			paramt = funct.In(i)
+		} else {
+			paramt = funct.In(numIn - 1).Elem()
 		}
-		params[i] = param
+		arg := (*C.DataValue)(unsafe.Pointer(uintptr(unsafe.Pointer(args)) + uintptr(i)*dataValueSize))
+		params[i] = signalParam(conn.signal, i, unpackDataValue(arg, engine), paramt)
 	}
-	funcv.Call(params[:numIn])
+	funcv.Call(params)
 }
 
 func cerror(cerr *C.error) error {
@@ -899,28 +1375,61 @@ func (win *Window) Root() Object {
 
 // Wait blocks the current goroutine until the window is closed.
 func (win *Window) Wait() {
-	// XXX Test this.
-	var m sync.Mutex
-	m.Lock()
-	RunMain(func() {
-		// TODO Must be able to wait for the same Window from multiple goroutines.
+	win.WaitContext(context.Background())
+}
+
+// WaitContext blocks the current goroutine until the window is closed,
+// or until ctx is done, in which case ctx.Err() is returned.
+func (win *Window) WaitContext(ctx context.Context) error {
+	hidden := make(chan struct{})
+	stop := func() { RunMain(func() { stopWaitingWindow(win.addr, hidden) }) }
+	err := runMain(ctx, func() {
 		// TODO If the window is not visible, must return immediately.
-		waitingWindows[win.addr] = &m
+		waitingWindows[win.addr] = append(waitingWindows[win.addr], hidden)
 		C.windowConnectHidden(win.addr)
-	})
-	m.Lock()
+	}, stop)
+	if err != nil {
+		return err
+	}
+	select {
+	case <-hidden:
+		return nil
+	case <-ctx.Done():
+		stop()
+		return ctx.Err()
+	}
 }
 
-var waitingWindows = make(map[unsafe.Pointer]*sync.Mutex)
+// waitingWindows holds the channels closed when each window is hidden.
+// The window is connected to hookWindowHidden while it has entries here.
+var waitingWindows = make(map[unsafe.Pointer][]chan struct{})
+
+// stopWaitingWindow drops the hidden channel of a cancelled wait on the
+// window at addr, if the window wasn't hidden meanwhile.
+//
+// This must be run from the main GUI thread.
+func stopWaitingWindow(addr unsafe.Pointer, hidden chan struct{}) {
+	waiting := waitingWindows[addr]
+	for i, ch := range waiting {
+		if ch == hidden {
+			waiting = append(waiting[:i], waiting[i+1:]...)
+			break
+		}
+	}
+	if len(waiting) > 0 {
+		waitingWindows[addr] = waiting
+	} else if _, ok := waitingWindows[addr]; ok {
+		delete(waitingWindows, addr)
+		C.windowDisconnectHidden(addr)
+	}
+}
 
 //export hookWindowHidden
 func hookWindowHidden(addr unsafe.Pointer) {
-	m, ok := waitingWindows[addr]
-	if !ok {
-		panic("window is not waiting")
+	for _, hidden := range waitingWindows[addr] {
+		close(hidden)
 	}
 	delete(waitingWindows, addr)
-	m.Unlock()
 }
 
 // Snapshot returns an image with the visible contents of the window.
@@ -987,7 +1496,92 @@ type TypeSpec struct {
 	// singleton value are directly accessible under the type name.
 	Singleton bool
 
+	// Enums optionally holds enumerations to be exposed as part of the
+	// type, each provided as a map from constant names to values of a
+	// named Go integer type, such as map[string]Status. Within QML code
+	// the constants are accessible under the type name, as in
+	// Person.Connected. See RegisterEnum for details.
+	Enums []interface{}
+
+	// Uncreatable optionally holds the reason reported when QML code attempts
+	// to create a value of the type. When set, QML code may still refer to the
+	// type, for example to access its enumerations, but values of the type
+	// must be created by Go code and handed to QML.
+	Uncreatable string
+
+	// Revision optionally defines the revision of the type members made
+	// available with the registered module version. Members tagged with a
+	// later revision via the "revision=N" qml tag option are hidden from QML
+	// code importing this version of the module. For example, to expose
+	// members tagged with revision 1 only from version 1.2 onwards:
+	//
+	//     qml.RegisterTypes("GoExtensions", 1, 0, []qml.TypeSpec{{Init: newPerson}})
+	//     qml.RegisterTypes("GoExtensions", 1, 2, []qml.TypeSpec{{Init: newPerson, Revision: 1}})
+	//
+	Revision int
+
+	// SingletonFactory optionally holds a function that creates the single
+	// value of the type used by each engine. The provided function must have
+	// the following type:
+	//
+	//     func(engine *qml.Engine) *CustomType
+	//
+	// The function is called the first time the singleton is accessed within
+	// each engine, so each engine may have a singleton value wired to its own
+	// services. Setting SingletonFactory implies Singleton, and Init becomes
+	// optional. If Init is also set, it's called with the value returned by
+	// the factory and its respective qml.Object.
+	//
+	// See Engine.Singleton for obtaining the singleton value used by an engine.
+	SingletonFactory interface{}
+
+	// Attached optionally holds a function that creates the value holding
+	// the QML attached properties of the type for a given object. The
+	// provided function must have the following type:
+	//
+	//     func(attachee qml.Object) *AttachedType
+	//
+	// Where AttachedType is an arbitrary Go type whose fields and methods
+	// are visible to QML under the registered type name within any object.
+	// For example, with a type registered as Validator and an attached type
+	// with an Error field, QML code may refer to Validator.error within any
+	// item, and the function is called once for each item that does so.
+	// No attached value is created for objects that are not associated
+	// with an engine, either directly or via their parents.
+	Attached interface{}
+
+	// DefaultProperty optionally holds the name of a []qml.Object field of
+	// the Go type that is used as the default property of the type within
+	// QML, so that objects declared inline within a value of the type are
+	// appended to the field. For example, with DefaultProperty set to
+	// "Children", the two Rectangle objects below are held by the Children
+	// field of the GoLayout value:
+	//
+	//     GoLayout {
+	//         Rectangle { width: 10; height: 10 }
+	//         Rectangle { width: 20; height: 20 }
+	//     }
+	//
+	// Objects held by a Go default property are not visually parented to
+	// the value, so types with displayable content that wish to display
+	// them must set their parent explicitly, for example from Complete.
+	DefaultProperty string
+
+	// Complete optionally holds a function that is called once a value
+	// created by QML code has all the property values and inline objects
+	// declared for it assigned. The provided function must have the
+	// following type:
+	//
+	//     func(value *CustomType, object qml.Object)
+	//
+	// Init is always called before Complete.
+	Complete interface{}
+
 	private struct{} // Force use of fields by name.
+
+	typ      reflect.Type // The *CustomType handled by Init or SingletonFactory.
+	location string
+	typeId   int
 }
 
 var types []*TypeSpec
@@ -1018,20 +1612,67 @@ func registerType(location string, major, minor int, spec *TypeSpec) error {
 	// Copy and hold a reference to the spec data.
 	localSpec := *spec
 
-	f := reflect.ValueOf(localSpec.Init)
-	ft := f.Type()
-	if ft.Kind() != reflect.Func {
-		return fmt.Errorf("TypeSpec.Init must be a function, got %#v", localSpec.Init)
+	if localSpec.SingletonFactory != nil {
+		ft := reflect.TypeOf(localSpec.SingletonFactory)
+		if ft.Kind() != reflect.Func || ft.NumIn() != 1 || ft.In(0) != typeEngine || ft.NumOut() != 1 {
+			return fmt.Errorf("TypeSpec.SingletonFactory must be a function with type func(*qml.Engine) *CustomType, got %#v", localSpec.SingletonFactory)
+		}
+		localSpec.typ = ft.Out(0)
+		if localSpec.typ.Kind() != reflect.Ptr || localSpec.typ.Elem().Kind() == reflect.Ptr {
+			return fmt.Errorf("TypeSpec.SingletonFactory's function must return a pointer type: %s", ft)
+		}
+		localSpec.Singleton = true
 	}
-	if ft.NumIn() != 2 {
-		return fmt.Errorf("TypeSpec.Init's function must accept two arguments: %s", ft)
+	if localSpec.Init != nil || localSpec.typ == nil {
+		f := reflect.ValueOf(localSpec.Init)
+		ft := f.Type()
+		if ft.Kind() != reflect.Func {
+			return fmt.Errorf("TypeSpec.Init must be a function, got %#v", localSpec.Init)
+		}
+		if ft.NumIn() != 2 {
+			return fmt.Errorf("TypeSpec.Init's function must accept two arguments: %s", ft)
+		}
+		firstArg := ft.In(0)
+		if firstArg.Kind() != reflect.Ptr || firstArg.Elem().Kind() == reflect.Ptr {
+			return fmt.Errorf("TypeSpec.Init's function must take a pointer type as the second argument: %s", ft)
+		}
+		if ft.In(1) != typeObject {
+			return fmt.Errorf("TypeSpec.Init's function must take qml.Object as the second argument: %s", ft)
+		}
+		if localSpec.typ != nil && firstArg != localSpec.typ {
+			return fmt.Errorf("TypeSpec.Init's function must take the type returned by SingletonFactory as the first argument: %s", ft)
+		}
+		localSpec.typ = firstArg
 	}
-	firstArg := ft.In(0)
-	if firstArg.Kind() != reflect.Ptr || firstArg.Elem().Kind() == reflect.Ptr {
-		return fmt.Errorf("TypeSpec.Init's function must take a pointer type as the second argument: %s", ft)
+	firstArg := localSpec.typ
+	if localSpec.Singleton && localSpec.Uncreatable != "" {
+		return fmt.Errorf("TypeSpec.Uncreatable cannot be used with singleton types")
+	}
+	if localSpec.Revision < 0 {
+		return fmt.Errorf("TypeSpec.Revision must not be negative: %d", localSpec.Revision)
+	}
+	if localSpec.Complete != nil {
+		ft := reflect.TypeOf(localSpec.Complete)
+		if ft.Kind() != reflect.Func || ft.NumIn() != 2 || ft.In(0) != firstArg || ft.In(1) != typeObject || ft.NumOut() != 0 {
+			return fmt.Errorf("TypeSpec.Complete must be a function with type func(%s, qml.Object), got %#v", firstArg, localSpec.Complete)
+		}
+		if localSpec.Singleton {
+			return fmt.Errorf("TypeSpec.Complete cannot be used with singleton types")
+		}
 	}
-	if ft.In(1) != typeObject {
-		return fmt.Errorf("TypeSpec.Init's function must take qml.Object as the second argument: %s", ft)
+	attachedInfo := (*C.GoTypeInfo)(nilPtr)
+	if localSpec.Attached != nil {
+		ft := reflect.TypeOf(localSpec.Attached)
+		if ft.Kind() != reflect.Func || ft.NumIn() != 1 || ft.In(0) != typeObject || ft.NumOut() != 1 {
+			return fmt.Errorf("TypeSpec.Attached must be a function with type func(qml.Object) *AttachedType, got %#v", localSpec.Attached)
+		}
+		if ft.Out(0).Kind() != reflect.Ptr || ft.Out(0).Elem().Kind() == reflect.Ptr {
+			return fmt.Errorf("TypeSpec.Attached's function must return a pointer type: %s", ft)
+		}
+		if localSpec.Singleton {
+			return fmt.Errorf("TypeSpec.Attached cannot be used with singleton types")
+		}
+		attachedInfo = typeInfo(reflect.New(ft.Out(0).Elem()).Interface())
 	}
 	customType := typeInfo(reflect.New(firstArg.Elem()).Interface())
 	if localSpec.Name == "" {
@@ -1040,16 +1681,56 @@ func registerType(location string, major, minor int, spec *TypeSpec) error {
 			panic("cannot determine registered type name; please provide one explicitly")
 		}
 	}
-
 	var err error
+	var enums []*enumSpec
+	for _, values := range localSpec.Enums {
+		enum, err := parseEnum(values)
+		if err != nil {
+			return err
+		}
+		enums = append(enums, enum)
+	}
+	var defaultProperty string
+	if localSpec.DefaultProperty != "" {
+		if localSpec.Singleton {
+			return fmt.Errorf("TypeSpec.DefaultProperty cannot be used with singleton types")
+		}
+		defaultProperty, err = defaultPropertyName(customType, firstArg.Elem(), localSpec.DefaultProperty)
+		if err != nil {
+			return err
+		}
+	}
+	if len(enums) > 0 || defaultProperty != "" || localSpec.Complete != nil {
+		// The type information is shared by all values of the Go type,
+		// so details specific to the registered type are held by a copy.
+		customType = copyTypeInfo(customType)
+		if len(enums) > 0 {
+			setTypeInfoEnums(customType, enums)
+		}
+		if defaultProperty != "" {
+			customType.defaultProperty = C.CString(defaultProperty)
+		}
+		if localSpec.Complete != nil {
+			customType.specComplete = 1
+		}
+	}
+
 	RunMain(func() {
+		for _, enum := range enums {
+			enumTypes[enum.typ] = true
+		}
 		cloc := C.CString(location)
 		cname := C.CString(localSpec.Name)
 		cres := C.int(0)
 		if localSpec.Singleton {
-			cres = C.registerSingleton(cloc, C.int(major), C.int(minor), cname, customType, unsafe.Pointer(&localSpec))
+			cres = C.registerSingleton(cloc, C.int(major), C.int(minor), cname, customType, unsafe.Pointer(&localSpec), C.int(localSpec.Revision))
 		} else {
-			cres = C.registerType(cloc, C.int(major), C.int(minor), cname, customType, unsafe.Pointer(&localSpec))
+			creason := nilCharPtr
+			if localSpec.Uncreatable != "" {
+				creason = C.CString(localSpec.Uncreatable)
+				defer C.free(unsafe.Pointer(creason))
+			}
+			cres = C.registerType(cloc, C.int(major), C.int(minor), cname, customType, unsafe.Pointer(&localSpec), C.int(localSpec.Revision), creason, attachedInfo)
 		}
 		// It doesn't look like it keeps references to these, but it's undocumented and unclear.
 		C.free(unsafe.Pointer(cloc))
@@ -1057,6 +1738,8 @@ func registerType(location string, major, minor int, spec *TypeSpec) error {
 		if cres == -1 {
 			err = fmt.Errorf("QML engine failed to register type; invalid type location or name?")
 		} else {
+			localSpec.location = location
+			localSpec.typeId = int(cres)
 			types = append(types, &localSpec)
 		}
 	})
@@ -1064,6 +1747,24 @@ func registerType(location string, major, minor int, spec *TypeSpec) error {
 	return err
 }
 
+// RegisterModule registers the provided major.minor version of the QML
+// module at location, so that QML code may import that version even if no
+// types were registered with it. This is useful when a new version of the
+// module does not change any of its types.
+//
+// RegisterModule requires Qt 5.9 or later.
+func RegisterModule(location string, major, minor int) {
+	var cres C.int
+	RunMain(func() {
+		cloc := C.CString(location)
+		cres = C.registerModule(cloc, C.int(major), C.int(minor))
+		C.free(unsafe.Pointer(cloc))
+	})
+	if cres == -1 {
+		panic("RegisterModule requires Qt 5.9 or later")
+	}
+}
+
 // RegisterConverter registers the convereter function to be called when a
 // value with the provided type name is obtained from QML logic. The function
 // must return the new value to be used in place of the original value.
@@ -1,16 +1,22 @@
 package qml_test
 
 import (
+	"bytes"
+	"context"
 	"encoding/base64"
+	"encoding/json"
+	"expvar"
 	"flag"
 	"fmt"
 	"image"
 	"image/color"
 	"io/ioutil"
 	"os"
+	"os/exec"
 	"reflect"
 	"regexp"
 	"runtime"
+	"sort"
 	"strings"
 	"testing"
 	"time"
@@ -22,7 +28,14 @@ import (
 	"path/filepath"
 )
 
-func init() { qml.SetupTesting() }
+func init() {
+	if os.Getenv("QML_TEST_RUN") != "" {
+		// Running as a child of TestRun. See runTestMain.
+		runTestMain()
+		os.Exit(0)
+	}
+	qml.SetupTesting()
+}
 
 func Test(t *testing.T) { TestingT(t) }
 
@@ -282,6 +295,45 @@ func (s *S) TestComponentCreateWindow(c *C) {
 	window.Hide()
 }
 
+func (s *S) TestWindowWait(c *C) {
+	component, err := s.engine.LoadString("file.qml", "import QtQuick 2.0\nItem {}")
+	c.Assert(err, IsNil)
+	window := component.CreateWindow(nil)
+	defer window.Destroy()
+	window.Show()
+	time.Sleep(100 * time.Millisecond)
+
+	// Cancelled waits must not leave anything behind.
+	for i := 0; i < 3; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		c.Assert(window.WaitContext(ctx), Equals, context.DeadlineExceeded)
+		cancel()
+	}
+
+	// Several goroutines may wait for the same window.
+	waited := make(chan bool)
+	for i := 0; i < 2; i++ {
+		go func() {
+			window.Wait()
+			waited <- true
+		}()
+	}
+	time.Sleep(100 * time.Millisecond)
+	window.Hide()
+	for i := 0; i < 2; i++ {
+		select {
+		case <-waited:
+		case <-time.After(3 * time.Second):
+			c.Fatalf("Wait did not return after the window was hidden")
+		}
+	}
+
+	// Hiding the window again with no one waiting is fine.
+	window.Show()
+	time.Sleep(100 * time.Millisecond)
+	window.Hide()
+}
+
 func (s *S) TestContextSpawn(c *C) {
 	context1 := s.engine.Context()
 	context2 := context1.Spawn()
@@ -429,6 +481,917 @@ func (s *S) TestResourcesIssue107(c *C) {
 	c.Assert(c.GetTestLog(), Matches, "(?s).*<Foo>.*<Bar>.*<Baz>.*<Buz>.*")
 }
 
+type ObservableType struct {
+	Name qml.Observable
+}
+
+func (s *S) TestObservable(c *C) {
+	value := &ObservableType{}
+	value.Name.Set("initial")
+	s.context.SetVar("value", value)
+
+	data := `
+		import QtQuick 2.0
+		Item {
+			property var name: value.name
+			function setName(name) { value.name = name }
+		}
+	`
+	component, err := s.engine.LoadString("file.qml", data)
+	c.Assert(err, IsNil)
+	root := component.Create(nil)
+	defer root.Destroy()
+
+	c.Assert(root.String("name"), Equals, "initial")
+	value.Name.Set("changed")
+	c.Assert(root.String("name"), Equals, "changed")
+	root.Call("setName", "from qml")
+	c.Assert(value.Name.Get(), Equals, "from qml")
+	c.Assert(root.String("name"), Equals, "from qml")
+}
+
+type TaggedType struct {
+	Title    string `qml:"heading"`
+	Secret   string `qml:"-"`
+	Internal int    `qml:",hidden"`
+	Count    int    `qml:",readonly,notify=countChanged"`
+}
+
+func (t *TaggedType) Total() int { return t.Count * 2 }
+func (t *TaggedType) Reset()     { t.Count = 0 }
+func (t *TaggedType) Debug()     {}
+
+func (s *S) TestTags(c *C) {
+	qml.TagMethods(&TaggedType{}, map[string]string{
+		"Total": "total,readonly",
+		"Reset": "clear",
+		"Debug": "-",
+	})
+	c.Check(func() { qml.TagMethods(&TaggedType{}, nil) }, Panics, "methods of type qml_test.TaggedType are already tagged")
+	c.Check(func() { qml.TagMethods(&ObservableType{}, map[string]string{"Missing": "-"}) }, Panics, "type qml_test.ObservableType has no method Missing")
+
+	value := &TaggedType{Title: "<title>", Secret: "<secret>", Count: 2}
+	s.context.SetVar("value", value)
+
+	data := `
+		import QtQuick 2.0
+		Item {
+			property var heading: value.heading
+			property var total: value.total
+			property int counts: 0
+			property string hidden: [typeof value.title, typeof value.secret, typeof value.internal, typeof value.reset, typeof value.debug].join(",")
+			Connections { target: value; onCountChanged: counts++ }
+			function clearIt() { value.clear() }
+		}
+	`
+	component, err := s.engine.LoadString("file.qml", data)
+	c.Assert(err, IsNil)
+	root := component.Create(nil)
+	defer root.Destroy()
+
+	c.Assert(root.String("heading"), Equals, "<title>")
+	c.Assert(root.Int("total"), Equals, 4)
+	c.Assert(root.String("hidden"), Equals, "undefined,undefined,undefined,undefined,undefined")
+
+	value.Count = 3
+	qml.Changed(value, &value.Count)
+	c.Assert(root.Int("counts"), Equals, 1)
+
+	root.Call("clearIt")
+	c.Assert(value.Count, Equals, 0)
+
+	c.Check(func() {
+		type BadTag struct {
+			Name string `qml:"name,bogus"`
+		}
+		s.context.SetVar("bad", &BadTag{})
+	}, Panics, `unknown option "bogus" in qml tag of BadTag.Name`)
+}
+
+type Status int
+
+const (
+	Disconnected Status = iota
+	Connecting
+	Connected
+)
+
+type EnumType struct {
+	State Status
+}
+
+func (e *EnumType) IsConnected(s Status) bool { return s == Connected }
+
+func (s *S) TestEnums(c *C) {
+	statuses := map[string]Status{
+		"Disconnected": Disconnected,
+		"Connecting":   Connecting,
+		"Connected":    Connected,
+	}
+	qml.RegisterEnum("GoEnums", 1, 0, statuses)
+	qml.RegisterTypes("GoEnums", 1, 0, []qml.TypeSpec{{
+		Name:  "Conn",
+		Init:  func(v *EnumType, obj qml.Object) {},
+		Enums: []interface{}{statuses},
+	}})
+
+	c.Check(func() { qml.RegisterEnum("GoEnums", 1, 0, map[string]int{"A": 1}) }, PanicMatches, "enumeration values must have a named type, got int")
+	c.Check(func() { qml.RegisterEnum("GoEnums", 1, 0, []Status{Connected}) }, PanicMatches, `enumeration must be a map from constant names to values, got \[\]qml_test.Status`)
+
+	value := &EnumType{State: Connecting}
+	s.context.SetVar("value", value)
+
+	data := `
+		import QtQuick 2.0
+		import GoEnums 1.0
+		Item {
+			property int connected: Status.Connected
+			property int connConnected: Conn.Connected
+			property bool connecting: value.state == Status.Connecting
+			function connect() {
+				value.state = Status.Connected
+				return value.isConnected(Status.Connected)
+			}
+		}
+	`
+	component, err := s.engine.LoadString("file.qml", data)
+	c.Assert(err, IsNil)
+	root := component.Create(nil)
+	defer root.Destroy()
+
+	c.Assert(root.Int("connected"), Equals, 2)
+	c.Assert(root.Int("connConnected"), Equals, 2)
+	c.Assert(root.Bool("connecting"), Equals, true)
+	c.Assert(root.Call("connect"), Equals, true)
+	c.Assert(value.State, Equals, Connected)
+}
+
+type RevisionedType struct {
+	Name  string
+	Email string `qml:",revision=1"`
+}
+
+func (s *S) TestTypeRevisions(c *C) {
+	initRevisioned := func(v *RevisionedType, obj qml.Object) {}
+	qml.RegisterTypes("GoRevisions", 1, 0, []qml.TypeSpec{{
+		Init: initRevisioned,
+	}, {
+		Name:        "Fixed",
+		Init:        initRevisioned,
+		Uncreatable: "created by Go only",
+	}})
+	qml.RegisterTypes("GoRevisions", 1, 2, []qml.TypeSpec{{
+		Init:     initRevisioned,
+		Revision: 1,
+	}})
+	qml.RegisterModule("GoRevisions", 1, 3)
+
+	c.Check(func() {
+		qml.RegisterTypes("GoRevisions", 1, 0, []qml.TypeSpec{{Init: initRevisioned, Singleton: true, Uncreatable: "reason"}})
+	}, PanicMatches, "TypeSpec.Uncreatable cannot be used with singleton types")
+
+	load := func(version, body string) error {
+		data := "import QtQuick 2.0\nimport GoRevisions " + version + "\nItem { " + body + " }"
+		component, err := s.engine.LoadString("file.qml", data)
+		if err == nil {
+			component.Create(nil).Destroy()
+		}
+		return err
+	}
+
+	c.Assert(load("1.0", `RevisionedType { name: "a" }`), IsNil)
+	c.Assert(load("1.0", `RevisionedType { email: "a" }`), ErrorMatches, `(?s).*email.*`)
+	c.Assert(load("1.2", `RevisionedType { email: "a" }`), IsNil)
+	c.Assert(load("1.3", `RevisionedType { email: "a" }`), IsNil)
+	c.Assert(load("1.0", `Fixed {}`), ErrorMatches, `(?s).*created by Go only.*`)
+}
+
+type FactoryType struct {
+	Name   string
+	engine *qml.Engine
+}
+
+func (s *S) TestSingletonFactory(c *C) {
+	var created []*FactoryType
+	qml.RegisterTypes("GoFactory", 1, 0, []qml.TypeSpec{{
+		Name: "Service",
+		SingletonFactory: func(engine *qml.Engine) *FactoryType {
+			value := &FactoryType{Name: fmt.Sprint("service ", len(created)), engine: engine}
+			created = append(created, value)
+			return value
+		},
+	}})
+
+	c.Check(func() {
+		qml.RegisterTypes("GoFactory", 1, 0, []qml.TypeSpec{{Name: "Bad", SingletonFactory: func() {}}})
+	}, PanicMatches, `TypeSpec.SingletonFactory must be a function with type func\(\*qml.Engine\) \*CustomType, got .*`)
+
+	data := `
+		import QtQuick 2.0
+		import GoFactory 1.0
+		Item { property string name: Service.name }
+	`
+	component, err := s.engine.LoadString("file.qml", data)
+	c.Assert(err, IsNil)
+	root := component.Create(nil)
+	defer root.Destroy()
+
+	c.Assert(root.String("name"), Equals, "service 0")
+	c.Assert(created, HasLen, 1)
+	c.Assert(created[0].engine, Equals, s.engine)
+	c.Assert(s.engine.Singleton("GoFactory", "Service"), Equals, created[0])
+
+	engine := qml.NewEngine()
+	defer engine.Destroy()
+	value := engine.Singleton("GoFactory", "Service").(*FactoryType)
+	c.Assert(created, HasLen, 2)
+	c.Assert(value, Equals, created[1])
+	c.Assert(value.engine, Equals, engine)
+
+	c.Check(func() { s.engine.Singleton("GoFactory", "Missing") }, Panics, "no singleton type Missing registered at GoFactory")
+
+	destroyed := qml.NewEngine()
+	destroyed.Destroy()
+	c.Check(func() { destroyed.Singleton("GoFactory", "Service") }, Panics, "engine already destroyed")
+}
+
+type ValidatorType struct {
+	Rules string
+}
+
+type ValidatorAttached struct {
+	Error    string
+	attachee qml.Object
+}
+
+func (s *S) TestAttached(c *C) {
+	var attached []*ValidatorAttached
+	qml.RegisterTypes("GoAttached", 1, 0, []qml.TypeSpec{{
+		Name:        "Validator",
+		Init:        func(v *ValidatorType, obj qml.Object) {},
+		Uncreatable: "Validator only offers attached properties",
+		Attached: func(attachee qml.Object) *ValidatorAttached {
+			value := &ValidatorAttached{attachee: attachee}
+			attached = append(attached, value)
+			return value
+		},
+	}})
+
+	c.Check(func() {
+		qml.RegisterTypes("GoAttached", 1, 0, []qml.TypeSpec{{Name: "Bad", Init: func(v *ValidatorType, obj qml.Object) {}, Attached: func() {}}})
+	}, PanicMatches, `TypeSpec.Attached must be a function with type func\(qml.Object\) \*AttachedType, got .*`)
+
+	data := `
+		import QtQuick 2.0
+		import GoAttached 1.0
+		Item {
+			Item { id: a; objectName: "a"; Validator.error: "too short" }
+			Item { id: b; objectName: "b" }
+			property string aError: a.Validator.error
+			function bError() { return b.Validator.error }
+		}
+	`
+	component, err := s.engine.LoadString("file.qml", data)
+	c.Assert(err, IsNil)
+	root := component.Create(nil)
+	defer root.Destroy()
+
+	c.Assert(attached, HasLen, 1)
+	c.Assert(attached[0].Error, Equals, "too short")
+	c.Assert(attached[0].attachee.String("objectName"), Equals, "a")
+	c.Assert(root.String("aError"), Equals, "too short")
+
+	c.Assert(root.Call("bError"), Equals, "")
+	c.Assert(attached, HasLen, 2)
+	c.Assert(attached[1].attachee.String("objectName"), Equals, "b")
+}
+
+type GoQuad struct {
+	Color       color.RGBA
+	UpdateCount int
+	quad        *qml.GeometryNode
+}
+
+func (q *GoQuad) UpdatePaintNode(sg *qml.SceneGraph) {
+	q.UpdateCount++
+
+	obj := sg.Object()
+	width := float32(obj.Int("width"))
+	height := float32(obj.Int("height"))
+	vertices := []float32{0, 0, width, 0, 0, height, width, height}
+
+	if sg.Len() == 0 {
+		q.quad = sg.AddGeometry(qml.DrawTriangleStrip, q.Color, vertices)
+	} else {
+		q.quad.SetColor(q.Color)
+		q.quad.SetVertices(qml.DrawTriangleStrip, vertices)
+	}
+}
+
+func (s *S) TestSceneGraph(c *C) {
+	var quads []*GoQuad
+	qml.RegisterTypes("GoSceneGraph", 1, 0, []qml.TypeSpec{{
+		Init: func(q *GoQuad, obj qml.Object) {
+			q.Color = color.RGBA{0, 255, 0, 255}
+			quads = append(quads, q)
+		},
+	}})
+
+	data := `
+		import QtQuick 2.0
+		import GoSceneGraph 1.0
+		Rectangle {
+			width: 200; height: 200
+			color: "black"
+			GoQuad {
+				objectName: "quad"
+				width: 100; height: 100; x: 50; y: 50
+			}
+		}
+	`
+	component, err := s.engine.LoadString("file.qml", data)
+	c.Assert(err, IsNil)
+
+	window := component.CreateWindow(nil)
+	defer window.Destroy()
+	window.Show()
+
+	// Qt doesn't hide the Window if we call it too quickly. :-(
+	time.Sleep(100 * time.Millisecond)
+
+	c.Assert(quads, HasLen, 1)
+	c.Assert(quads[0].UpdateCount, Equals, 1)
+
+	image := window.Snapshot()
+	c.Assert(image.At(25, 25), Equals, color.RGBA{0, 0, 0, 255})
+	c.Assert(image.At(100, 100), Equals, color.RGBA{0, 255, 0, 255})
+
+	quads[0].Color = color.RGBA{0, 0, 255, 255}
+	window.Root().ObjectByName("quad").Call("update")
+	time.Sleep(100 * time.Millisecond)
+
+	c.Assert(quads[0].UpdateCount, Equals, 2)
+
+	image = window.Snapshot()
+	c.Assert(image.At(100, 100), Equals, color.RGBA{0, 0, 255, 255})
+}
+
+type GoCanvas struct {
+	PaintCount int
+	TextWidth  float64
+}
+
+func (cv *GoCanvas) Paint(p *qml.Painter) {
+	cv.PaintCount++
+
+	obj := p.Object()
+	width := float64(obj.Int("width"))
+	height := float64(obj.Int("height"))
+
+	p.FillRect(0, 0, width/2, height, color.RGBA{255, 0, 0, 255})
+
+	var path qml.Path
+	path.MoveTo(width/2, 0).LineTo(width, 0).LineTo(width, height).LineTo(width/2, height).Close()
+	p.FillPath(&path, &qml.LinearGradient{
+		X1: width / 2, X2: width,
+		Stops: []qml.GradientStop{{Pos: 0, Color: color.RGBA{0, 0, 255, 255}}, {Pos: 1, Color: color.RGBA{0, 0, 255, 255}}},
+	})
+
+	p.SetFont(qml.Font{PixelSize: 12})
+	cv.TextWidth, _ = p.TextSize("Hello")
+}
+
+func (s *S) TestPainter2D(c *C) {
+	var canvases []*GoCanvas
+	qml.RegisterTypes("GoPainter", 1, 0, []qml.TypeSpec{{
+		Init: func(cv *GoCanvas, obj qml.Object) { canvases = append(canvases, cv) },
+	}})
+
+	data := `
+		import QtQuick 2.0
+		import GoPainter 1.0
+		Rectangle {
+			width: 200; height: 200
+			color: "black"
+			GoCanvas {
+				width: 100; height: 100; x: 50; y: 50
+			}
+		}
+	`
+	component, err := s.engine.LoadString("file.qml", data)
+	c.Assert(err, IsNil)
+
+	window := component.CreateWindow(nil)
+	defer window.Destroy()
+	window.Show()
+
+	// Qt doesn't hide the Window if we call it too quickly. :-(
+	time.Sleep(100 * time.Millisecond)
+
+	c.Assert(canvases, HasLen, 1)
+	c.Assert(canvases[0].PaintCount, Equals, 1)
+	c.Assert(canvases[0].TextWidth > 0, Equals, true)
+
+	image := window.Snapshot()
+	c.Assert(image.At(25, 25), Equals, color.RGBA{0, 0, 0, 255})
+	c.Assert(image.At(75, 100), Equals, color.RGBA{255, 0, 0, 255})
+	c.Assert(image.At(125, 100), Equals, color.RGBA{0, 0, 255, 255})
+}
+
+type GoInputRect struct {
+	focusReasons []qml.FocusReason
+	mouse        []qml.MouseEvent
+	hover        []qml.HoverEvent
+	wheel        []qml.WheelEvent
+	touch        []qml.TouchEvent
+	keys         []qml.KeyEvent
+}
+
+func (r *GoInputRect) Paint(p *qml.Painter) {}
+
+func (r *GoInputRect) FocusInEvent(e *qml.FocusEvent) {
+	r.focusReasons = append(r.focusReasons, e.Reason)
+}
+
+func (r *GoInputRect) MousePressEvent(e *qml.MouseEvent) {
+	if e.Button == qml.RightButton {
+		e.Ignore()
+	}
+	r.mouse = append(r.mouse, *e)
+}
+
+func (r *GoInputRect) HoverMoveEvent(e *qml.HoverEvent) {
+	r.hover = append(r.hover, *e)
+}
+
+func (r *GoInputRect) WheelEvent(e *qml.WheelEvent) {
+	r.wheel = append(r.wheel, *e)
+}
+
+func (r *GoInputRect) TouchEvent(e *qml.TouchEvent) {
+	r.touch = append(r.touch, *e)
+}
+
+func (r *GoInputRect) KeyPressEvent(e *qml.KeyEvent) {
+	if e.Key != 'A' {
+		e.Ignore()
+	}
+	r.keys = append(r.keys, *e)
+}
+
+func (s *S) TestInputEvents(c *C) {
+	var rects []*GoInputRect
+	qml.RegisterTypes("GoInput", 1, 0, []qml.TypeSpec{{
+		Init: func(r *GoInputRect, obj qml.Object) { rects = append(rects, r) },
+	}})
+
+	data := `
+		import QtQuick 2.0
+		import GoInput 1.0
+		Rectangle {
+			width: 200; height: 200
+			property int qmlKey
+			GoInputRect {
+				objectName: "input"
+				width: 100; height: 100; x: 50; y: 50
+				Keys.onPressed: { qmlKey = event.key; event.accepted = true }
+			}
+		}
+	`
+	component, err := s.engine.LoadString("file.qml", data)
+	c.Assert(err, IsNil)
+
+	window := component.CreateWindow(nil)
+	defer window.Destroy()
+	window.Show()
+
+	// Qt doesn't hide the Window if we call it too quickly. :-(
+	time.Sleep(100 * time.Millisecond)
+
+	c.Assert(rects, HasLen, 1)
+	r := rects[0]
+	c.Assert(r.focusReasons, HasLen, 0)
+
+	root := window.Root()
+	input := root.ObjectByName("input")
+	c.Assert(cpptest.AcceptedMouseButtons(input)&qml.LeftButton, Equals, qml.LeftButton)
+	c.Assert(cpptest.AcceptHoverEvents(input), Equals, true)
+
+	// Accepted and ignored mouse events.
+	mods := qml.ShiftModifier | qml.ControlModifier
+	c.Assert(cpptest.SendMouseEvent(input, cpptest.MouseButtonPress, 10, 20, 60, 70, qml.LeftButton, qml.LeftButton, mods), Equals, true)
+	c.Assert(cpptest.SendMouseEvent(input, cpptest.MouseButtonPress, 30, 40, 80, 90, qml.RightButton, qml.LeftButton|qml.RightButton, 0), Equals, false)
+	c.Assert(r.mouse, HasLen, 2)
+	c.Assert(r.mouse[0].X, Equals, 10.0)
+	c.Assert(r.mouse[0].Y, Equals, 20.0)
+	c.Assert(r.mouse[0].SceneX, Equals, 60.0)
+	c.Assert(r.mouse[0].SceneY, Equals, 70.0)
+	c.Assert(r.mouse[0].Button, Equals, qml.LeftButton)
+	c.Assert(r.mouse[0].Buttons, Equals, qml.LeftButton)
+	c.Assert(r.mouse[0].Modifiers, Equals, mods)
+	c.Assert(r.mouse[0].IsAccepted(), Equals, true)
+	c.Assert(r.mouse[1].Button, Equals, qml.RightButton)
+	c.Assert(r.mouse[1].Buttons, Equals, qml.LeftButton|qml.RightButton)
+	c.Assert(r.mouse[1].IsAccepted(), Equals, false)
+
+	// Hover positions are mapped onto the scene by the item.
+	c.Assert(cpptest.SendHoverEvent(input, cpptest.HoverMove, 5, 6, qml.AltModifier), Equals, true)
+	c.Assert(r.hover, HasLen, 1)
+	c.Assert([]float64{r.hover[0].X, r.hover[0].Y, r.hover[0].SceneX, r.hover[0].SceneY}, DeepEquals, []float64{5, 6, 55, 56})
+	c.Assert(r.hover[0].Modifiers, Equals, qml.AltModifier)
+
+	c.Assert(cpptest.SendWheelEvent(input, 7, 8, 0, 120, qml.MiddleButton, qml.MetaModifier), Equals, true)
+	c.Assert(r.wheel, HasLen, 1)
+	c.Assert([]float64{r.wheel[0].X, r.wheel[0].Y, r.wheel[0].SceneX, r.wheel[0].SceneY}, DeepEquals, []float64{7, 8, 57, 58})
+	c.Assert(r.wheel[0].AngleDeltaX, Equals, 0.0)
+	c.Assert(r.wheel[0].AngleDeltaY, Equals, 120.0)
+	c.Assert(r.wheel[0].Buttons, Equals, qml.MiddleButton)
+	c.Assert(r.wheel[0].Modifiers, Equals, qml.MetaModifier)
+
+	point := qml.TouchPoint{ID: 3, State: qml.TouchPointPressed, X: 11, Y: 12, Pressure: 0.5}
+	c.Assert(cpptest.SendTouchEvent(input, cpptest.TouchBegin, point, qml.ShiftModifier), Equals, true)
+	c.Assert(r.touch, HasLen, 1)
+	c.Assert(r.touch[0].Points, DeepEquals, []qml.TouchPoint{point})
+	c.Assert(r.touch[0].Modifiers, Equals, qml.ShiftModifier)
+
+	input.Call("forceActiveFocus")
+	c.Assert(r.focusReasons, DeepEquals, []qml.FocusReason{qml.OtherFocusReason})
+
+	// Keys accepted in Go do not reach the Keys handlers in QML,
+	// but ignored ones do.
+	c.Assert(cpptest.SendKeyEvent(input, cpptest.KeyPress, 'A', qml.ShiftModifier, "A"), Equals, true)
+	c.Assert(root.Int("qmlKey"), Equals, 0)
+	c.Assert(cpptest.SendKeyEvent(input, cpptest.KeyPress, 'B', 0, "b"), Equals, true)
+	c.Assert(root.Int("qmlKey"), Equals, int('B'))
+	c.Assert(r.keys, HasLen, 2)
+	c.Assert(r.keys[0].Key, Equals, qml.Key('A'))
+	c.Assert(r.keys[0].Text, Equals, "A")
+	c.Assert(r.keys[0].Modifiers, Equals, qml.ShiftModifier)
+	c.Assert(r.keys[0].AutoRepeat, Equals, false)
+	c.Assert(r.keys[1].Key, Equals, qml.Key('B'))
+	c.Assert(r.keys[1].Text, Equals, "b")
+	c.Assert(r.keys[1].IsAccepted(), Equals, false)
+}
+
+type GoLifecycleRect struct {
+	Label string
+
+	obj          qml.Object
+	calls        []string
+	labelAtBegin string
+	geometry     qml.Rect
+	parents      []qml.Object
+	children     []qml.ItemChange
+}
+
+func (r *GoLifecycleRect) Paint(p *qml.Painter) {}
+
+func (r *GoLifecycleRect) ClassBegin() {
+	r.calls = append(r.calls, "ClassBegin")
+	r.labelAtBegin = r.Label
+}
+
+func (r *GoLifecycleRect) ComponentComplete() {
+	r.calls = append(r.calls, "ComponentComplete")
+	r.obj.Set("implicitWidth", 10*len(r.Label))
+	r.obj.Set("implicitHeight", 20)
+}
+
+func (r *GoLifecycleRect) GeometryChanged(newGeometry, oldGeometry qml.Rect) {
+	r.geometry = newGeometry
+}
+
+func (r *GoLifecycleRect) ItemChanged(change qml.ItemChange) {
+	switch change.Kind {
+	case qml.ItemParentChange:
+		parent, _ := change.Value.(qml.Object)
+		r.parents = append(r.parents, parent)
+	case qml.ItemChildAdded, qml.ItemChildRemoved:
+		r.children = append(r.children, change)
+	}
+}
+
+func (s *S) TestItemLifecycle(c *C) {
+	var rects []*GoLifecycleRect
+	qml.RegisterTypes("GoLifecycle", 1, 0, []qml.TypeSpec{{
+		Init: func(r *GoLifecycleRect, obj qml.Object) {
+			r.obj = obj
+			rects = append(rects, r)
+		},
+	}})
+
+	data := `
+		import QtQuick 2.0
+		import GoLifecycle 1.0
+		Row {
+			objectName: "row"
+			GoLifecycleRect { label: "hello" }
+			GoLifecycleRect { label: "hi" }
+		}
+	`
+	component, err := s.engine.LoadString("file.qml", data)
+	c.Assert(err, IsNil)
+	root := component.Create(nil)
+	defer root.Destroy()
+
+	c.Assert(rects, HasLen, 2)
+	c.Assert(rects[0].calls, DeepEquals, []string{"ClassBegin", "ComponentComplete"})
+	c.Assert(rects[0].labelAtBegin, Equals, "")
+	c.Assert(rects[0].Label, Equals, "hello")
+
+	c.Assert(rects[0].geometry, Equals, qml.Rect{X: 0, Y: 0, Width: 50, Height: 20})
+	c.Assert(rects[1].geometry, Equals, qml.Rect{X: 50, Y: 0, Width: 20, Height: 20})
+	c.Assert(root.Int("width"), Equals, 70)
+
+	c.Assert(rects[0].parents, HasLen, 1)
+	c.Assert(rects[0].parents[0].String("objectName"), Equals, "row")
+}
+
+func (s *S) TestItemChildren(c *C) {
+	var rects []*GoLifecycleRect
+	qml.RegisterTypes("GoChildren", 1, 0, []qml.TypeSpec{{
+		Init: func(r *GoLifecycleRect, obj qml.Object) {
+			r.obj = obj
+			rects = append(rects, r)
+		},
+	}})
+
+	data := `
+		import QtQuick 2.0
+		import GoChildren 1.0
+		Item {
+			GoLifecycleRect {
+				GoLifecycleRect { objectName: "a" }
+				GoLifecycleRect { objectName: "b" }
+			}
+		}
+	`
+	component, err := s.engine.LoadString("file.qml", data)
+	c.Assert(err, IsNil)
+	root := component.Create(nil)
+	defer root.Destroy()
+
+	// Go-backed children are provided as objects.
+	c.Assert(rects, HasLen, 3)
+	outer := rects[0]
+	c.Assert(outer.children, HasLen, 2)
+	for i, change := range outer.children {
+		c.Assert(change.Kind, Equals, qml.ItemChildAdded)
+		child, ok := change.Value.(qml.Object)
+		c.Assert(ok, Equals, true)
+		c.Assert(child.Interface(), Equals, rects[i+1])
+	}
+
+	// Children moved elsewhere are provided on removal.
+	root.ObjectByName("a").Set("parent", root)
+	c.Assert(outer.children, HasLen, 3)
+	c.Assert(outer.children[2].Kind, Equals, qml.ItemChildRemoved)
+	c.Assert(outer.children[2].Value.(qml.Object).String("objectName"), Equals, "a")
+
+	// Children being destroyed are not.
+	root.ObjectByName("b").Destroy()
+	for i := 0; i < 30 && len(outer.children) < 4; i++ {
+		time.Sleep(100 * time.Millisecond)
+	}
+	c.Assert(outer.children, HasLen, 4)
+	c.Assert(outer.children[3].Kind, Equals, qml.ItemChildRemoved)
+	c.Assert(outer.children[3].Value, IsNil)
+}
+
+type GoLayout struct {
+	Children []qml.Object
+
+	completed int
+}
+
+func (l *GoLayout) Paint(p *qml.Painter) {}
+
+func (s *S) TestDefaultProperty(c *C) {
+	var layouts []*GoLayout
+	qml.RegisterTypes("GoLayouts", 1, 0, []qml.TypeSpec{{
+		Init:            func(l *GoLayout, obj qml.Object) { layouts = append(layouts, l) },
+		DefaultProperty: "Children",
+		Complete: func(l *GoLayout, obj qml.Object) {
+			l.completed++
+			x := 0
+			for _, child := range l.Children {
+				child.Set("parent", obj)
+				child.Set("x", x)
+				x += child.Int("width")
+			}
+		},
+	}})
+
+	c.Check(func() {
+		qml.RegisterTypes("GoLayouts", 1, 0, []qml.TypeSpec{{Name: "Bad", Init: func(l *GoLayout, obj qml.Object) {}, DefaultProperty: "Missing"}})
+	}, PanicMatches, `TypeSpec.DefaultProperty refers to unknown field GoLayout.Missing`)
+	c.Check(func() {
+		qml.RegisterTypes("GoLayouts", 1, 0, []qml.TypeSpec{{Name: "Bad", Init: func(l *GoLayout, obj qml.Object) {}, Complete: func() {}}})
+	}, PanicMatches, `TypeSpec.Complete must be a function with type func\(\*qml_test.GoLayout, qml.Object\), got .*`)
+
+	data := `
+		import QtQuick 2.0
+		import GoLayouts 1.0
+		GoLayout {
+			Rectangle { objectName: "a"; width: 10; height: 10 }
+			Rectangle { objectName: "b"; width: 20; height: 20 }
+		}
+	`
+	component, err := s.engine.LoadString("file.qml", data)
+	c.Assert(err, IsNil)
+	root := component.Create(nil)
+	defer root.Destroy()
+
+	c.Assert(layouts, HasLen, 1)
+	c.Assert(layouts[0].completed, Equals, 1)
+	c.Assert(layouts[0].Children, HasLen, 2)
+	c.Assert(layouts[0].Children[0].String("objectName"), Equals, "a")
+	c.Assert(layouts[0].Children[1].String("objectName"), Equals, "b")
+	c.Assert(layouts[0].Children[1].Int("x"), Equals, 10)
+	c.Assert(layouts[0].Children[1].Property("parent"), Equals, layouts[0])
+}
+
+func (s *S) TestStatsMetrics(c *C) {
+	component, err := s.engine.LoadString("file.qml", "import QtQuick 2.0\nItem { signal doIt(); function emitIt() { doIt() } }")
+	c.Assert(err, IsNil)
+	root := component.Create(nil)
+	defer root.Destroy()
+
+	root.On("doIt", func() {})
+	root.Call("emitIt")
+	qml.RunMain(func() {})
+
+	stats := qml.Stats()
+	c.Assert(stats.RunMainCalls > 0, Equals, true)
+	c.Assert(stats.IdleIterations > 0, Equals, true)
+	c.Assert(stats.SignalCalls, Equals, 1)
+
+	var buf bytes.Buffer
+	c.Assert(qml.WriteMetrics(&buf), IsNil)
+	c.Assert(buf.String(), Matches, "(?s).*# TYPE qml_values_alive gauge\n.*")
+	c.Assert(buf.String(), Matches, "(?s).*\nqml_signal_calls_total 1\n.*")
+
+	qml.PublishStats("qml_test")
+	qml.PublishStats("qml_test")
+	var published qml.Statistics
+	c.Assert(json.Unmarshal([]byte(expvar.Get("qml_test").String()), &published), IsNil)
+	c.Assert(published.SignalCalls, Equals, 1)
+}
+
+func (s *S) TestRunMainAsync(c *C) {
+	var order []int
+	var done <-chan struct{}
+	for i := 0; i < 100; i++ {
+		i := i
+		done = qml.RunMainAsync(func() { order = append(order, i) })
+	}
+	select {
+	case <-done:
+	case <-time.After(3 * time.Second):
+		c.Fatalf("asynchronous functions did not run")
+	}
+	c.Assert(order, HasLen, 100)
+	for i := range order {
+		c.Assert(order[i], Equals, i)
+	}
+
+	// Synchronous calls still work after the batch is done.
+	var ran bool
+	qml.RunMain(func() { ran = true })
+	c.Assert(ran, Equals, true)
+}
+
+func (s *S) TestRunMainAsyncBatch(c *C) {
+	// Functions scheduled while the main thread is busy run in one batch.
+	var batch []int
+	var done <-chan struct{}
+	qml.RunMain(func() {
+		for i := 0; i < 3; i++ {
+			i := i
+			done = qml.RunMainAsync(func() { batch = append(batch, i) })
+		}
+	})
+	select {
+	case <-done:
+	case <-time.After(3 * time.Second):
+		c.Fatalf("asynchronous functions did not run")
+	}
+	c.Assert(batch, DeepEquals, []int{0, 1, 2})
+
+	// Once the batch is done the idle timer must stop firing.
+	time.Sleep(50 * time.Millisecond)
+	before := qml.Stats().IdleIterations
+	time.Sleep(100 * time.Millisecond)
+	c.Assert(qml.Stats().IdleIterations-before < 5, Equals, true)
+}
+
+func (s *S) TestRunMainAsyncPanic(c *C) {
+	var ran []int
+	var first, done <-chan struct{}
+	qml.RunMain(func() {
+		first = qml.RunMainAsync(func() { ran = append(ran, 0) })
+		qml.RunMainAsync(func() { panic("<boom>") })
+		done = qml.RunMainAsync(func() { ran = append(ran, 2) })
+	})
+	select {
+	case <-done:
+	case <-time.After(3 * time.Second):
+		c.Fatalf("asynchronous functions did not run")
+	}
+	<-first
+	c.Assert(ran, DeepEquals, []int{0, 2})
+	c.Assert(c.GetTestLog(), Matches, "(?s).*qml_test.go:[0-9]+: panic in function run by RunMainAsync: <boom>.*")
+
+	// The queue is still usable afterwards.
+	<-qml.RunMainAsync(func() { ran = append(ran, 3) })
+	c.Assert(ran, DeepEquals, []int{0, 2, 3})
+}
+
+func (s *S) TestRunMainContext(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var ran bool
+	c.Assert(qml.RunMainContext(ctx, func() { ran = true }), Equals, context.Canceled)
+	c.Assert(ran, Equals, false)
+
+	r := strings.NewReader("import QtQuick 2.0\nItem {}")
+	_, err := s.engine.LoadContext(ctx, "file.qml", r)
+	c.Assert(err, Equals, context.Canceled)
+	c.Assert(r.Len(), Not(Equals), 0)
+
+	// Give up while the GUI thread is busy.
+	busy := qml.RunMainAsync(func() { time.Sleep(200 * time.Millisecond) })
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	c.Assert(qml.RunMainContext(ctx, func() { ran = true }), Equals, context.DeadlineExceeded)
+	<-busy
+
+	// Give up while the function itself is running.
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	c.Assert(qml.RunMainContext(ctx, func() { time.Sleep(200 * time.Millisecond) }), Equals, context.DeadlineExceeded)
+
+	qml.RunMain(func() { ran = true })
+	c.Assert(ran, Equals, true)
+
+	component, err := s.engine.LoadStringContext(context.Background(), "file.qml", "import QtQuick 2.0\nItem { width: 42 }")
+	c.Assert(err, IsNil)
+	root := component.Create(nil)
+	defer root.Destroy()
+	c.Assert(root.Int("width"), Equals, 42)
+}
+
+func (s *S) TestWatchdog(c *C) {
+	qml.SetWatchdog(&qml.Watchdog{Threshold: 50 * time.Millisecond, History: 2})
+	defer qml.SetWatchdog(nil)
+
+	qml.RunMain(func() {})
+	qml.RunMain(func() { time.Sleep(100 * time.Millisecond) })
+
+	c.Assert(c.GetTestLog(), Matches, "(?s).*GUI thread blocked for more than 50ms; called from:\n.*TestWatchdog.*")
+	c.Assert(strings.Count(c.GetTestLog(), "GUI thread"), Equals, 1)
+
+	calls := qml.GUICalls()
+	c.Assert(calls, HasLen, 2)
+	c.Assert(calls[0].Duration < 50*time.Millisecond, Equals, true)
+	c.Assert(calls[1].Duration >= 100*time.Millisecond, Equals, true)
+	c.Assert(calls[1].Stack, Matches, "(?s).*TestWatchdog.*")
+
+	qml.RunMain(func() {})
+	calls = qml.GUICalls()
+	c.Assert(calls, HasLen, 2)
+	c.Assert(calls[0].Duration >= 100*time.Millisecond, Equals, true)
+}
+
+type reentrantLogger struct {
+	obj   qml.Object
+	texts []string
+}
+
+func (l *reentrantLogger) QmlOutput(msg qml.LogMessage) error {
+	l.texts = append(l.texts, msg.Text())
+	if len(l.texts) == 1 {
+		l.obj.Call("log", "<second>")
+	}
+	return nil
+}
+
+func (s *S) TestReentrantLogger(c *C) {
+	component, err := s.engine.LoadString("file.qml", `import QtQuick 2.0; Item { function log(s) { console.log(s) } }`)
+	c.Assert(err, IsNil)
+	root := component.Create(nil)
+	defer root.Destroy()
+
+	logger := &reentrantLogger{obj: root}
+	qml.SetLogger(logger)
+	root.Call("log", "<first>")
+	qml.SetLogger(c)
+
+	c.Assert(logger.texts, HasLen, 2)
+	c.Assert(logger.texts[0], Matches, ".*<first>.*")
+	c.Assert(logger.texts[1], Matches, ".*<second>.*")
+}
+
 type TestData struct {
 	*C
 	engine           *qml.Engine
@@ -1083,6 +2046,89 @@ var tests = []struct {
 		Done:    func(c *TestData) { c.root.Call("log", c.root) },
 		DoneLog: "Width is 300",
 	},
+	{
+		Summary: "Obtain the object representing a Go value with ObjectOf",
+		QML:     `Item { function log(v) { console.log("String is", v.stringValue) } }`,
+		Done: func(c *TestData) {
+			value := &GoType{StringValue: "<content>"}
+			obj := c.engine.ObjectOf(value)
+			c.Check(obj.Interface(), Equals, value)
+			c.Check(obj.String("stringValue"), Equals, "<content>")
+			c.Check(c.engine.ObjectOf(value).Addr(), Equals, obj.Addr())
+			c.Check(c.engine.ObjectOf(obj), Equals, obj)
+			c.Check(func() { c.engine.ObjectOf(42) }, Panics, "cannot obtain QML object for non-struct value: 42")
+			c.root.Call("log", obj)
+		},
+		DoneLog: "String is <content>",
+	},
+	{
+		Summary: "Change and release the ownership of Go values",
+		QML:     `Item {}`,
+		Done: func(c *TestData) {
+			value := &GoType{}
+			obj := c.engine.ObjectOf(value)
+			c.Check(c.engine.ObjectOwnership(obj), Equals, qml.CppOwnership)
+			c.engine.SetObjectOwnership(obj, qml.JavaScriptOwnership)
+			c.Check(c.engine.ObjectOwnership(obj), Equals, qml.JavaScriptOwnership)
+			c.engine.SetObjectOwnership(obj, qml.CppOwnership)
+			c.Check(c.engine.ObjectOwnership(obj), Equals, qml.CppOwnership)
+
+			c.engine.Release(value)
+			c.Check(c.engine.ObjectOf(value).Addr(), Not(Equals), obj.Addr())
+		},
+	},
+	{
+		Summary: "Switching an object back to CppOwnership makes it the one reused",
+		QML:     `Item {}`,
+		Done: func(c *TestData) {
+			value := &GoType{}
+			obj1 := c.engine.ObjectOf(value)
+			c.engine.SetObjectOwnership(obj1, qml.JavaScriptOwnership)
+			obj2 := c.engine.ObjectOf(value)
+			c.Assert(obj2.Addr(), Not(Equals), obj1.Addr())
+
+			c.engine.SetObjectOwnership(obj1, qml.CppOwnership)
+			c.Check(c.engine.ObjectOf(value).Addr(), Equals, obj1.Addr())
+		},
+	},
+	{
+		Summary: "Release values that were never handed to QML",
+		QML:     `Item {}`,
+		Done: func(c *TestData) {
+			c.engine.Release(nil)
+			c.engine.Release(struct{ list []int }{})
+			c.engine.Release(&GoType{})
+		},
+	},
+	{
+		Summary: "Report live values and signal connections",
+		QML:     `Item { signal doIt() }`,
+		Done: func(c *TestData) {
+			qml.CollectStacks(true)
+			defer qml.CollectStacks(false)
+
+			c.engine.ObjectOf(&GoType{})
+			c.root.On("doIt", func() {})
+
+			report := qml.Live()
+			var found bool
+			for _, v := range report.Values {
+				if v.Type == "*qml_test.GoType" && v.Owner == "cpp" && v.Engine == c.engine && strings.Contains(v.Stack, "qml_test") {
+					found = true
+				}
+			}
+			c.Check(found, Equals, true)
+
+			found = false
+			for _, conn := range report.Connections {
+				if conn.Signal == "doIt" && conn.Count > 0 {
+					found = true
+				}
+			}
+			c.Check(found, Equals, true)
+			c.Check(report.String(), Matches, "(?s).*\\*qml_test.GoType.*doIt.*")
+		},
+	},
 	{
 		Summary: "Create a QML-defined component in Go",
 		QML:     `Item { property var comp: Component { Rectangle { width: 300 } } }`,
@@ -1104,6 +2150,170 @@ var tests = []struct {
 		},
 		DoneLog: "item destroyed",
 	},
+	{
+		Summary: "Disconnect Go functions from QML signals",
+		QML:     `Item { signal doIt(int n); function emitIt(n) { doIt(n) } }`,
+		Done: func(c *TestData) {
+			var calls []string
+			conn := c.root.On("doIt", func(n int) { calls = append(calls, fmt.Sprint("a", n)) })
+			c.root.On("doIt", func(n int) { calls = append(calls, fmt.Sprint("b", n)) })
+			c.root.Call("emitIt", 1)
+			conn.Disconnect()
+			c.root.Call("emitIt", 2)
+			c.root.Off("doIt")
+			c.root.Call("emitIt", 3)
+			sort.Strings(calls)
+			c.Check(calls, DeepEquals, []string{"a1", "b1", "b2"})
+		},
+	},
+	{
+		Summary: "Disconnect Go functions connected by name and by signature",
+		QML:     `Item { signal doIt(int n); function emitIt(n) { doIt(n) } }`,
+		Done: func(c *TestData) {
+			var calls []string
+			c.root.On("doIt", func(n int) { calls = append(calls, fmt.Sprint("a", n)) })
+			c.root.On("doIt(int)", func(n int) { calls = append(calls, fmt.Sprint("b", n)) })
+			c.root.Call("emitIt", 1)
+			c.root.Off("doIt")
+			c.root.Call("emitIt", 2)
+			c.root.On("doIt", func(n int) { calls = append(calls, fmt.Sprint("c", n)) })
+			c.root.Off("doIt(int)")
+			c.root.Call("emitIt", 3)
+			sort.Strings(calls)
+			c.Check(calls, DeepEquals, []string{"a1", "b1"})
+		},
+	},
+	{
+		Summary: "Connect to QML signals with variadic functions and nil values",
+		QML: `
+			Item {
+				signal doIt(int n, string s, var v)
+				function emitIt() { doIt(42, "hi", null) }
+			}
+		`,
+		Done: func(c *TestData) {
+			var all, rest []interface{}
+			var vars []qml.Object
+			c.root.On("doIt", func(args ...interface{}) { all = args })
+			c.root.On("doIt", func(n int, args ...interface{}) { rest = args })
+			c.root.On("doIt(int,QString,QVariant)", func(n int, s string, v qml.Object) { vars = append(vars, v) })
+			c.root.Call("emitIt")
+			c.Check(all, DeepEquals, []interface{}{42, "hi", nil})
+			c.Check(rest, DeepEquals, []interface{}{"hi", nil})
+			c.Check(vars, DeepEquals, []qml.Object{nil})
+
+			c.Check(func() { c.root.On("doIt(int)", func() {}) }, Panics, `object does not expose a "doIt(int)" signal`)
+		},
+	},
+	{
+		Summary: "Deliver QML signals to Go channels",
+		QML:     `Item { signal doIt(int n, string s); function emitIt(n, s) { doIt(n, s) } }`,
+		Done: func(c *TestData) {
+			first := make(chan float64, 1)
+			all := make(chan []interface{}, 1)
+			none := make(chan struct{})
+			c.root.Notify("doIt", first, qml.NotifyBlock)
+			c.root.Notify("doIt", all, qml.NotifyBlock)
+			c.root.Notify("doIt", none, qml.NotifyDrop)
+			c.root.Call("emitIt", 42, "hi")
+			c.Check(<-first, Equals, float64(42))
+			c.Check(<-all, DeepEquals, []interface{}{42, "hi"})
+			select {
+			case <-none:
+				c.Fatalf("notification was not dropped")
+			default:
+			}
+			c.Check(func() { c.root.Notify("doIt", 42, qml.NotifyDrop) }, Panics, "value provided to Notify is not a channel that can be sent to")
+		},
+	},
+	{
+		Summary: "Refuse delivering object signal parameters to Go channels",
+		QML:     `MouseArea { signal doIt(Item item, int n) }`,
+		Done: func(c *TestData) {
+			c.Check(func() { c.root.Notify("clicked", make(chan interface{}), qml.NotifyDrop) }, Panics,
+				`signal "clicked" has object parameters that cannot be delivered to a channel`)
+			c.Check(func() { c.root.Notify("doIt", make(chan []interface{}), qml.NotifyDrop) }, Panics,
+				`signal "doIt" has object parameters that cannot be delivered to a channel`)
+
+			// Only delivered parameters matter.
+			clicks := make(chan struct{}, 1)
+			c.root.Notify("clicked", clicks, qml.NotifyDrop)
+		},
+	},
+	{
+		Summary: "Bind Go fields to QML properties",
+		QML:     `Item { property string text: "initial"; width: 10 }`,
+		Done: func(c *TestData) {
+			model := &struct {
+				Name string
+				Size int
+			}{Name: "go", Size: 4}
+
+			name := qml.Bind(c.root, "text", &model.Name)
+			c.Check(c.root.String("text"), Equals, "go")
+			c.root.Set("text", "qml")
+			c.Check(model.Name, Equals, "qml")
+			model.Name = "go again"
+			qml.Changed(model, &model.Name)
+			c.Check(c.root.String("text"), Equals, "go again")
+
+			size := qml.BindWith(c.root, "width", &model.Size,
+				func(v interface{}) interface{} { return v.(int) * 10 },
+				func(v interface{}) interface{} { return int(v.(float64)) / 10 },
+			)
+			c.Check(c.root.Int("width"), Equals, 40)
+			c.root.Set("width", 50)
+			c.Check(model.Size, Equals, 5)
+
+			name.Unbind()
+			size.Unbind()
+			name.Unbind()
+			c.root.Set("text", "unbound")
+			c.Check(model.Name, Equals, "go again")
+		},
+	},
+	{
+		Summary: "Drop bindings to destroyed objects and disconnected properties",
+		QML:     `Item { property var comp: Component { Item { property string text } } }`,
+		Done: func(c *TestData) {
+			model := &struct{ Name string }{Name: "go"}
+
+			obj := c.root.Object("comp").Create(nil)
+			qml.Bind(obj, "text", &model.Name)
+			c.Check(obj.String("text"), Equals, "go")
+			obj.Destroy()
+			time.Sleep(100 * time.Millisecond)
+			model.Name = "destroyed"
+			qml.Changed(model, &model.Name)
+
+			obj = c.root.Object("comp").Create(nil)
+			defer obj.Destroy()
+			qml.Bind(obj, "text", &model.Name)
+			obj.Off("textChanged")
+			model.Name = "off"
+			qml.Changed(model, &model.Name)
+			c.Check(obj.String("text"), Equals, "destroyed")
+		},
+	},
+	{
+		Summary: "Watch property changes",
+		QML:     `Item { width: 10 }`,
+		Done: func(c *TestData) {
+			var changes []string
+			conn := c.root.Watch("width", func(old, new int) {
+				changes = append(changes, fmt.Sprintf("%d->%d", old, new))
+			})
+			c.root.Set("width", 20)
+			c.root.Set("width", 30)
+			conn.Disconnect()
+			c.root.Set("width", 40)
+			conn.Disconnect()
+			c.Check(changes, DeepEquals, []string{"10->20", "20->30"})
+
+			c.Check(func() { c.root.Watch("missing", func(a, b int) {}) }, Panics, `object does not have a "missing" property`)
+			c.Check(func() { c.root.Watch("width", func(a int) {}) }, Panics, "function provided to Watch must accept the old and new values as parameters of the same type")
+		},
+	},
 	{
 		Summary: "Errors connecting to QML signals",
 		QML:     `Item { signal doIt() }`,
@@ -1434,3 +2644,70 @@ func (s *S) TestTable(c *C) {
 		}
 	}
 }
+
+// runTestMain runs the event loop several times from the initial
+// goroutine of a separate process, as the test suite itself runs within
+// an event loop that must not be terminated. Results are printed out
+// for TestRun to verify.
+func runTestMain() {
+	var quitting int
+	qml.OnAboutToQuit(func() { quitting++ })
+
+	err := qml.Run(func() error {
+		qml.RunMain(func() { qml.Quit(3) })
+		select {} // Run returns without waiting for f.
+	})
+	fmt.Printf("quit: %v; about to quit: %d\n", err, quitting)
+
+	appName := func() error {
+		engine := qml.NewEngine()
+		defer engine.Destroy()
+		component, err := engine.LoadString("file.qml", "import QtQuick 2.0\nItem { property string name: Qt.application.name }")
+		if err != nil {
+			return err
+		}
+		root := component.Create(nil)
+		defer root.Destroy()
+		fmt.Printf("name: %q\n", root.String("name"))
+		return nil
+	}
+
+	opts := &qml.Options{Args: []string{"qml-test"}, ApplicationName: "test-app", OpenGL: qml.OpenGLSoftware}
+	err = qml.RunWithOptions(opts, appName)
+	fmt.Printf("options: %v; about to quit: %d\n", err, quitting)
+
+	err = qml.RunWithOptions(&qml.Options{Args: []string{"qml-test"}}, appName)
+	fmt.Printf("defaults: %v; about to quit: %d\n", err, quitting)
+
+	err = qml.RunWithOptions(&qml.Options{OpenGL: 42}, func() error { panic("must not run") })
+	fmt.Printf("invalid: %v\n", err)
+
+	qml.SetQuitOnLastWindowClosed(true)
+	err = qml.Run(func() error {
+		engine := qml.NewEngine()
+		component, err := engine.LoadString("file.qml", "import QtQuick 2.0\nimport QtQuick.Window 2.0\nWindow {}")
+		if err != nil {
+			return err
+		}
+		win := component.CreateWindow(nil)
+		win.Show()
+		win.Call("close")
+		select {}
+	})
+	fmt.Printf("last window: %v; about to quit: %d\n", err, quitting)
+}
+
+func (s *S) TestRun(c *C) {
+	cmd := exec.Command(os.Args[0])
+	cmd.Env = append(os.Environ(), "QML_TEST_RUN=1")
+	output, err := cmd.CombinedOutput()
+	c.Assert(err, IsNil, Commentf("%s", output))
+	c.Assert(string(output), Matches, "(?s)(.*\n)?"+
+		"quit: application exited with code 3; about to quit: 1\n(.*\n)?"+
+		"name: \"test-app\"\n"+
+		"options: <nil>; about to quit: 2\n(.*\n)?"+
+		"name: \"qml-test\"\n"+
+		"defaults: <nil>; about to quit: 3\n(.*\n)?"+
+		"invalid: OpenGL implementation 42 is not supported by Qt .*\n(.*\n)?"+
+		"last window: <nil>; about to quit: 4\n.*")
+}
@@ -0,0 +1,124 @@
+package qml
+
+// #include <stdlib.h>
+// #include "capi.h"
+import "C"
+
+import (
+	"image"
+	"image/color"
+	"unsafe"
+)
+
+// DrawingMode defines how the vertices of a geometry node are drawn.
+type DrawingMode int
+
+const (
+	DrawPoints DrawingMode = iota
+	DrawLines
+	DrawLineLoop
+	DrawLineStrip
+	DrawTriangles
+	DrawTriangleStrip
+	DrawTriangleFan
+)
+
+// SceneGraph is provided to UpdatePaintNode methods on Go types that
+// build their displayable content out of scene graph nodes.
+//
+// The nodes added to a scene graph are retained across UpdatePaintNode
+// calls, so the method only has to build them when the scene graph is
+// empty, and may otherwise update the nodes built previously. The scene
+// graph may be discarded and handed over empty again at any time, such
+// as when the item is moved into a different window, so nodes must be
+// rebuilt whenever Len returns zero.
+//
+// UpdatePaintNode is called on the rendering thread while the main GUI
+// thread is blocked, and a new call may be requested by calling the
+// "update" method of the respective object.
+type SceneGraph struct {
+	obj  Object
+	item unsafe.Pointer
+	root unsafe.Pointer
+}
+
+// Object returns the underlying object being updated.
+func (sg *SceneGraph) Object() Object {
+	return sg.obj
+}
+
+// Len returns the number of nodes in the scene graph.
+func (sg *SceneGraph) Len() int {
+	return int(C.sgNodeChildCount(sg.root))
+}
+
+// Clear removes and deletes all nodes in the scene graph. Nodes
+// previously added to it must not be used anymore.
+func (sg *SceneGraph) Clear() {
+	C.sgNodeRemoveAll(sg.root)
+}
+
+// AddGeometry adds to the scene graph a node that draws the provided
+// vertices with a flat color, according to mode. The vertices are
+// provided as consecutive x and y coordinates in the item's coordinate
+// system.
+func (sg *SceneGraph) AddGeometry(mode DrawingMode, c color.RGBA, vertices []float32) *GeometryNode {
+	cvertices, cverticesLen := floatsPtr(vertices)
+	node := C.sgNodeAddGeometry(sg.root, C.int(mode), cvertices, cverticesLen, colorRGBA(c))
+	return &GeometryNode{node}
+}
+
+// AddTexture adds to the scene graph a node that draws a copy of img
+// within the provided rectangle of the item's coordinate system.
+func (sg *SceneGraph) AddTexture(img image.Image, x, y, width, height float64) *TextureNode {
+	node := C.sgNodeAddTexture(sg.root, sg.item, newCImage(img), C.double(x), C.double(y), C.double(width), C.double(height))
+	return &TextureNode{node, sg.item}
+}
+
+// GeometryNode is a scene graph node that draws vertices with a flat color.
+// It must only be used within the UpdatePaintNode method of the item
+// that holds it.
+type GeometryNode struct {
+	node unsafe.Pointer
+}
+
+// SetVertices replaces the vertices drawn by the node and how they are drawn.
+func (n *GeometryNode) SetVertices(mode DrawingMode, vertices []float32) {
+	cvertices, cverticesLen := floatsPtr(vertices)
+	C.sgGeometrySetVertices(n.node, C.int(mode), cvertices, cverticesLen)
+}
+
+// SetColor changes the color the node's vertices are drawn with.
+func (n *GeometryNode) SetColor(c color.RGBA) {
+	C.sgGeometrySetColor(n.node, colorRGBA(c))
+}
+
+// TextureNode is a scene graph node that draws an image.
+// It must only be used within the UpdatePaintNode method of the item
+// that holds it.
+type TextureNode struct {
+	node unsafe.Pointer
+	item unsafe.Pointer
+}
+
+// SetImage replaces the image drawn by the node with a copy of img.
+func (n *TextureNode) SetImage(img image.Image) {
+	C.sgTextureSetImage(n.node, n.item, newCImage(img))
+}
+
+// SetRect changes the rectangle of the item's coordinate system
+// the node's image is drawn within.
+func (n *TextureNode) SetRect(x, y, width, height float64) {
+	C.sgTextureSetRect(n.node, C.double(x), C.double(y), C.double(width), C.double(height))
+}
+
+func floatsPtr(values []float32) (*C.float, C.int) {
+	if len(values) == 0 {
+		return nil, 0
+	}
+	return (*C.float)(unsafe.Pointer(&values[0])), C.int(len(values))
+}
+
+func colorRGBA(c color.RGBA) C.uint {
+	return C.uint(uint32(c.A)<<24 | uint32(c.R)<<16 | uint32(c.G)<<8 | uint32(c.B))
+}
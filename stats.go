@@ -1,7 +1,15 @@
 package qml
 
 import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 var stats *Statistics
@@ -9,7 +17,9 @@ var statsMutex sync.Mutex
 
 func Stats() (snapshot Statistics) {
 	statsMutex.Lock()
-	snapshot = *stats
+	if stats != nil {
+		snapshot = *stats
+	}
 	statsMutex.Unlock()
 	return
 }
@@ -33,6 +43,7 @@ func ResetStats() {
 	// These are absolute values:
 	stats.EnginesAlive = old.EnginesAlive
 	stats.ValuesAlive = old.ValuesAlive
+	stats.ConnectionsAlive = old.ConnectionsAlive
 	statsMutex.Unlock()
 	return
 }
@@ -41,6 +52,31 @@ type Statistics struct {
 	EnginesAlive     int
 	ValuesAlive      int
 	ConnectionsAlive int
+
+	// RunMainCalls is the number of functions handed over to the main
+	// GUI thread by RunMain or RunMainAsync, and RunMainWait is the total time
+	// they waited before starting to run. RunMainMaxWait is the
+	// longest of these waits.
+	RunMainCalls   int
+	RunMainWait    time.Duration
+	RunMainMaxWait time.Duration
+
+	// IdleIterations is the number of times the idle timer fired
+	// in the main GUI thread to run functions handed over by RunMain.
+	IdleIterations int
+
+	// SignalCalls is the number of signals delivered to Go functions.
+	SignalCalls int
+
+	// ImageRequests is the number of images requested from Go image
+	// providers, and ImageRequestTime is the total time taken by them.
+	ImageRequests    int
+	ImageRequestTime time.Duration
+
+	// PaintCalls is the number of calls made to Paint methods, and
+	// PaintTime is the total time taken by them.
+	PaintCalls int
+	PaintTime  time.Duration
 }
 
 func (stats *Statistics) enginesAlive(delta int) {
@@ -66,3 +102,219 @@ func (stats *Statistics) connectionsAlive(delta int) {
 		statsMutex.Unlock()
 	}
 }
+
+func (stats *Statistics) runMainCall(wait time.Duration) {
+	if stats != nil {
+		statsMutex.Lock()
+		stats.RunMainCalls++
+		stats.RunMainWait += wait
+		if wait > stats.RunMainMaxWait {
+			stats.RunMainMaxWait = wait
+		}
+		statsMutex.Unlock()
+	}
+}
+
+func (stats *Statistics) idleIteration() {
+	if stats != nil {
+		statsMutex.Lock()
+		stats.IdleIterations++
+		statsMutex.Unlock()
+	}
+}
+
+func (stats *Statistics) signalCall() {
+	if stats != nil {
+		statsMutex.Lock()
+		stats.SignalCalls++
+		statsMutex.Unlock()
+	}
+}
+
+func (stats *Statistics) imageRequest(took time.Duration) {
+	if stats != nil {
+		statsMutex.Lock()
+		stats.ImageRequests++
+		stats.ImageRequestTime += took
+		statsMutex.Unlock()
+	}
+}
+
+func (stats *Statistics) paintCall(took time.Duration) {
+	if stats != nil {
+		statsMutex.Lock()
+		stats.PaintCalls++
+		stats.PaintTime += took
+		statsMutex.Unlock()
+	}
+}
+
+var statsStacks int32
+
+// CollectStacks defines whether the stack of the goroutine that hands a
+// Go value to QML is recorded, so that it may be reported by Live.
+// Collecting stacks is expensive, and is meant for debugging only.
+func CollectStacks(enabled bool) {
+	if enabled {
+		atomic.StoreInt32(&statsStacks, 1)
+	} else {
+		atomic.StoreInt32(&statsStacks, 0)
+	}
+}
+
+// captureStack returns the current goroutine stack, skipping the
+// provided number of frames, if CollectStacks is enabled.
+func captureStack(skip int) []uintptr {
+	if atomic.LoadInt32(&statsStacks) == 0 {
+		return nil
+	}
+	pc := make([]uintptr, 32)
+	return pc[:runtime.Callers(skip+2, pc)]
+}
+
+// guiStack holds the stack of the goroutine waiting on the function being
+// run by the main GUI thread, if stacks are being collected. That's the
+// stack of interest for values created as a side effect of the function.
+var guiStack []uintptr
+
+// creationStack returns the stack to be recorded for a value being
+// created in the main GUI thread, if CollectStacks is enabled.
+func creationStack() []uintptr {
+	if guiStack != nil {
+		return guiStack
+	}
+	return captureStack(2)
+}
+
+func formatStack(pc []uintptr) string {
+	if len(pc) == 0 {
+		return ""
+	}
+	var buf bytes.Buffer
+	frames := runtime.CallersFrames(pc)
+	for {
+		frame, more := frames.Next()
+		fmt.Fprintf(&buf, "%s\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+		if !more {
+			break
+		}
+	}
+	return buf.String()
+}
+
+// LiveReport details the Go values and the signal connections that are
+// alive in QML at a given time, as returned by the Live function.
+type LiveReport struct {
+	Values      []LiveValues
+	Connections []LiveConnections
+}
+
+// LiveValues holds the number of Go values alive in QML that share
+// the same type, ownership, engine, and creation stack.
+type LiveValues struct {
+	Type   string  // Go type of the values, such as "*main.Person".
+	Owner  string  // Either "cpp", "js", or "released".
+	Engine *Engine // Nil for values created by QML that were never used.
+	Stack  string  // Empty unless the stack was recorded; see CollectStacks.
+	Count  int
+}
+
+// LiveConnections holds the number of Go functions connected to the
+// signal with the given name.
+type LiveConnections struct {
+	Signal string
+	Count  int
+}
+
+// Live returns a report of the Go values and signal connections that are
+// currently alive in QML. Comparing the reports obtained at different
+// times is useful to track down which values are leaking.
+func Live() *LiveReport {
+	type valuesKey struct {
+		typ    string
+		owner  valueOwner
+		engine *Engine
+		stack  string
+	}
+	values := make(map[valuesKey]int)
+	signals := make(map[string]int)
+	add := func(fold *valueFold) {
+		key := valuesKey{
+			typ:    reflect.TypeOf(fold.gvalue).String(),
+			owner:  fold.owner,
+			engine: fold.engine,
+			stack:  formatStack(fold.stack),
+		}
+		values[key]++
+	}
+	RunMain(func() {
+		for _, engine := range engines {
+			for _, fold := range engine.values {
+				for ; fold != nil; fold = fold.next {
+					add(fold)
+				}
+			}
+		}
+		for fold := range typeNew {
+			add(fold)
+		}
+		for _, conn := range connectedFunction {
+			signals[conn.signal]++
+		}
+	})
+
+	report := &LiveReport{}
+	for key, count := range values {
+		report.Values = append(report.Values, LiveValues{
+			Type:   key.typ,
+			Owner:  key.owner.String(),
+			Engine: key.engine,
+			Stack:  key.stack,
+			Count:  count,
+		})
+	}
+	for signal, count := range signals {
+		report.Connections = append(report.Connections, LiveConnections{signal, count})
+	}
+	sort.Slice(report.Values, func(i, j int) bool {
+		vi, vj := &report.Values[i], &report.Values[j]
+		if vi.Count != vj.Count {
+			return vi.Count > vj.Count
+		}
+		if vi.Type != vj.Type {
+			return vi.Type < vj.Type
+		}
+		return vi.Owner < vj.Owner
+	})
+	sort.Slice(report.Connections, func(i, j int) bool {
+		ci, cj := &report.Connections[i], &report.Connections[j]
+		if ci.Count != cj.Count {
+			return ci.Count > cj.Count
+		}
+		return ci.Signal < cj.Signal
+	})
+	return report
+}
+
+// String returns a human readable dump of the report.
+func (r *LiveReport) String() string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "Values alive:\n")
+	for _, v := range r.Values {
+		engine := "no engine"
+		if v.Engine != nil {
+			engine = fmt.Sprintf("engine %p", v.Engine.addr)
+		}
+		fmt.Fprintf(&buf, "%6d %s (owner %s, %s)\n", v.Count, v.Type, v.Owner, engine)
+		if v.Stack != "" {
+			for _, line := range strings.Split(strings.TrimRight(v.Stack, "\n"), "\n") {
+				fmt.Fprintf(&buf, "           %s\n", line)
+			}
+		}
+	}
+	fmt.Fprintf(&buf, "Connections alive:\n")
+	for _, conn := range r.Connections {
+		fmt.Fprintf(&buf, "%6d %s\n", conn.Count, conn.Signal)
+	}
+	return buf.String()
+}
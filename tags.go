@@ -0,0 +1,108 @@
+package qml
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// memberTag holds the options defined for a Go field or method via
+// a qml struct tag, or via TagMethods.
+type memberTag struct {
+	name     string
+	readOnly bool
+	hidden   bool
+	notify   string
+	revision int
+}
+
+// parseMemberTag parses the qml tag for the named member of type t.
+func parseMemberTag(tag string, t reflect.Type, member string) memberTag {
+	var result memberTag
+	if tag == "" {
+		return result
+	}
+	if tag == "-" {
+		result.hidden = true
+		return result
+	}
+	options := strings.Split(tag, ",")
+	result.name = options[0]
+	for _, option := range options[1:] {
+		switch {
+		case option == "readonly":
+			result.readOnly = true
+		case option == "hidden":
+			result.hidden = true
+		case strings.HasPrefix(option, "notify="):
+			result.notify = option[len("notify="):]
+			if result.notify == "" {
+				panic(fmt.Sprintf("empty notify signal in qml tag of %s.%s", t.Name(), member))
+			}
+		case strings.HasPrefix(option, "revision="):
+			revision, err := strconv.Atoi(option[len("revision="):])
+			if err != nil || revision < 0 {
+				panic(fmt.Sprintf("invalid revision in qml tag of %s.%s: %q", t.Name(), member, option))
+			}
+			result.revision = revision
+		default:
+			panic(fmt.Sprintf("unknown option %q in qml tag of %s.%s", option, t.Name(), member))
+		}
+	}
+	return result
+}
+
+var (
+	methodTags      = make(map[reflect.Type]map[string]string)
+	methodTagsMutex sync.Mutex
+)
+
+// TagMethods defines qml tags for the methods of the type of sample, as
+// Go does not support struct tags on methods. The tags map holds method
+// names as keys and the respective tags as values, following the same
+// syntax used by qml tags on struct fields:
+//
+//     qml.TagMethods(&Person{}, map[string]string{
+//         "Reset":    "clear",
+//         "Internal": "-",
+//         "Age":      "age,readonly,notify=ageChanged",
+//     })
+//
+// Tags on a method without arguments and with a single result may define
+// it as readonly, in which case the method is exposed to QML as a read-only
+// property rather than as a method, even without a respective setter.
+//
+// TagMethods must be called before values of the type are first handed
+// to QML, and panics if it's called multiple times for the same type.
+func TagMethods(sample interface{}, tags map[string]string) {
+	t := reflect.TypeOf(sample)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	tp := reflect.PtrTo(t)
+	for name := range tags {
+		if _, ok := tp.MethodByName(name); !ok {
+			panic(fmt.Sprintf("type %s has no method %s", t, name))
+		}
+	}
+	methodTagsMutex.Lock()
+	defer methodTagsMutex.Unlock()
+	if _, ok := methodTags[t]; ok {
+		panic(fmt.Sprintf("methods of type %s are already tagged", t))
+	}
+	tagsCopy := make(map[string]string, len(tags))
+	for name, tag := range tags {
+		tagsCopy[name] = tag
+	}
+	methodTags[t] = tagsCopy
+}
+
+// typeMethodTags returns the method tags defined for t via TagMethods.
+func typeMethodTags(t reflect.Type) map[string]string {
+	methodTagsMutex.Lock()
+	tags := methodTags[t]
+	methodTagsMutex.Unlock()
+	return tags
+}
@@ -0,0 +1,194 @@
+package qml
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"runtime/trace"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Watchdog holds the settings for monitoring the functions run in
+// the main GUI thread by RunMain. See SetWatchdog for details.
+type Watchdog struct {
+	// Threshold is the time a function may block the main GUI thread
+	// before a warning is logged. Zero disables the warnings.
+	Threshold time.Duration
+
+	// History is the number of recent calls retained for GUICalls.
+	History int
+
+	// Trace defines whether each function run in the main GUI thread
+	// is wrapped in a runtime/trace region named "qml.RunMain", so that
+	// the GUI thread work is visible via "go tool trace".
+	Trace bool
+}
+
+// GUICall holds details about a function run in the main GUI thread
+// by RunMain while a watchdog was enabled.
+type GUICall struct {
+	Start    time.Time     // When the function started running.
+	Wait     time.Duration // How long it waited before running.
+	Duration time.Duration // How long it blocked the main GUI thread.
+	Stack    string        // Stack of the goroutine that called RunMain.
+}
+
+type watchdogState struct {
+	Watchdog
+
+	mutex   sync.Mutex
+	history []guiCall
+	next    int
+}
+
+type guiCall struct {
+	start    time.Time
+	wait     time.Duration
+	duration time.Duration
+	stack    []uintptr
+}
+
+var (
+	watchdog      *watchdogState
+	watchdogMutex sync.Mutex
+)
+
+// SetWatchdog enables the monitoring of functions run in the main GUI
+// thread via RunMain, which includes all the calls made by the qml
+// package on behalf of Go code. While enabled, the duration and caller
+// stack of each function are recorded, a warning is sent to the qml
+// logger (see SetLogger) whenever the main GUI thread stays blocked
+// for longer than the configured threshold, and runtime/trace regions
+// are optionally emitted.
+//
+// Monitoring has a cost for every call, and is disabled by providing
+// a nil watchdog.
+func SetWatchdog(w *Watchdog) {
+	var state *watchdogState
+	if w != nil {
+		state = &watchdogState{Watchdog: *w}
+		if w.History > 0 {
+			state.history = make([]guiCall, 0, w.History)
+		}
+	}
+	watchdogMutex.Lock()
+	watchdog = state
+	watchdogMutex.Unlock()
+}
+
+// GUICalls returns the most recent functions run in the main GUI thread
+// via RunMain while the current watchdog was enabled, oldest first.
+// The number of calls retained is defined by Watchdog.History.
+func GUICalls() []GUICall {
+	watchdogMutex.Lock()
+	w := watchdog
+	watchdogMutex.Unlock()
+	if w == nil {
+		return nil
+	}
+
+	w.mutex.Lock()
+	history := make([]guiCall, 0, len(w.history))
+	history = append(history, w.history[w.next:]...)
+	history = append(history, w.history[:w.next]...)
+	w.mutex.Unlock()
+
+	calls := make([]GUICall, len(history))
+	for i, call := range history {
+		calls[i] = GUICall{
+			Start:    call.start,
+			Wait:     call.wait,
+			Duration: call.duration,
+			Stack:    formatStack(call.stack),
+		}
+	}
+	return calls
+}
+
+// watchdogWrap returns f wrapped so that it's monitored by the current
+// watchdog, if any. The recorded caller stack skips the provided number
+// of frames, with zero identifying the caller of watchdogWrap.
+func watchdogWrap(skip int, f func()) func() {
+	watchdogMutex.Lock()
+	w := watchdog
+	watchdogMutex.Unlock()
+	if w == nil {
+		return f
+	}
+
+	stack := make([]uintptr, 32)
+	stack = stack[:runtime.Callers(skip+2, stack)]
+	queued := time.Now()
+
+	return func() {
+		start := time.Now()
+		var timer *time.Timer
+		if w.Threshold > 0 {
+			// A stall is reported only once, while it's still ongoing,
+			// so that even a GUI thread that never unblocks is noticed.
+			// The full duration is available via GUICalls.
+			timer = time.AfterFunc(w.Threshold, func() {
+				w.warn(stack, fmt.Sprintf("GUI thread blocked for more than %v", w.Threshold))
+			})
+		}
+		if w.Trace {
+			trace.WithRegion(context.Background(), "qml.RunMain", f)
+		} else {
+			f()
+		}
+		took := time.Since(start)
+		if timer != nil {
+			timer.Stop()
+		}
+		w.record(guiCall{start, start.Sub(queued), took, stack})
+	}
+}
+
+func (w *watchdogState) record(call guiCall) {
+	if w.History <= 0 {
+		return
+	}
+	w.mutex.Lock()
+	if len(w.history) < w.History {
+		w.history = append(w.history, call)
+	} else {
+		w.history[w.next] = call
+		w.next = (w.next + 1) % w.History
+	}
+	w.mutex.Unlock()
+}
+
+func (w *watchdogState) warn(stack []uintptr, text string) {
+	msg := &goLogMessage{severity: LogWarning, text: text}
+	if len(stack) > 0 {
+		frame, _ := runtime.CallersFrames(stack).Next()
+		msg.file = frame.File
+		msg.line = frame.Line
+	}
+	if callers := formatStack(stack); callers != "" {
+		msg.text += "; called from:\n" + strings.TrimSuffix(callers, "\n")
+	}
+	logOutput(msg)
+}
+
+// goLogMessage is a LogMessage originated in Go code rather than in Qt.
+type goLogMessage struct {
+	severity LogSeverity
+	text     string
+	file     string
+	line     int
+}
+
+func (m *goLogMessage) Severity() LogSeverity { return m.severity }
+func (m *goLogMessage) Text() string          { return m.text }
+func (m *goLogMessage) File() string          { return m.file }
+func (m *goLogMessage) Line() int             { return m.line }
+
+func (m *goLogMessage) String() string {
+	return fmt.Sprintf("%s:%d: %s", filepath.Base(m.file), m.line, m.text)
+}
+
+func (*goLogMessage) privateMarker() {}